@@ -0,0 +1,90 @@
+package main
+
+import (
+	"gorm.io/gorm"
+	"log"
+	"missing-persons-scrapper/pkg/api"
+	"missing-persons-scrapper/pkg/countries/croatia"
+	"missing-persons-scrapper/pkg/countries/romania"
+	"missing-persons-scrapper/pkg/engine"
+	"missing-persons-scrapper/pkg/imagestore"
+	"missing-persons-scrapper/pkg/persons"
+	"missing-persons-scrapper/pkg/scraper"
+	"missing-persons-scrapper/pkg/storage"
+)
+
+// Everything the commands read of the scrapped persons of a country.
+type countrySource interface {
+	api.Source
+	Records() ([]persons.Record, error)
+	ImageHashes() (map[int]string, error)
+}
+
+// A country that is scraped, by its own package or by a definition file of the engine.
+type country struct {
+	code    string
+	store   storage.Store
+	source  countrySource
+	scraper func(deps scraper.Dependencies) scraper.Scraper
+}
+
+/*
+*
+Returns Croatia, Romania and the countries defined in COUNTRIES_DIR (see engine.FromEnv). The tables of the
+defined countries are created by migrate up.
+*/
+func countries(db *gorm.DB, images imagestore.Store) []country {
+	list := []country{
+		{
+			code:    croatia.Country,
+			store:   croatia.NewStore(db),
+			source:  croatia.NewSource(db, images),
+			scraper: func(deps scraper.Dependencies) scraper.Scraper { return croatia.NewScraper(deps) },
+		},
+		{
+			code:    romania.Country,
+			store:   romania.NewStore(db),
+			source:  romania.NewSource(db, images),
+			scraper: func(deps scraper.Dependencies) scraper.Scraper { return romania.NewScraper(deps) },
+		},
+	}
+
+	defs := definitions()
+
+	// the tables of the countries with their own package
+	reserved := map[string]bool{croatia.Croatia_Scrapper_Table: true, romania.Romania_Scrapper_Table: true}
+	for _, def := range defs {
+		for _, c := range list {
+			if c.code == def.Country || reserved[def.Tables().Raw] {
+				log.Fatalf("country %s (%s) is defined twice\n", def.Country, def.Name)
+			}
+		}
+
+		list = append(list, country{
+			code:    def.Country,
+			store:   engine.NewStore(db, def),
+			source:  engine.NewSource(db, images, def),
+			scraper: func(deps scraper.Dependencies) scraper.Scraper { return engine.NewScraper(deps, def) },
+		})
+	}
+
+	return list
+}
+
+func personSources(countries []country) []persons.Source {
+	list := make([]persons.Source, len(countries))
+	for i, c := range countries {
+		list[i] = c.source
+	}
+
+	return list
+}
+
+func apiSources(countries []country) []api.Source {
+	list := make([]api.Source, len(countries))
+	for i, c := range countries {
+		list[i] = c.source
+	}
+
+	return list
+}
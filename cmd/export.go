@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"gorm.io/gorm"
+	"io"
+	"log"
+	"missing-persons-scrapper/pkg/persons"
+	"os"
+	"strconv"
+	"time"
+)
+
+/*
+*
+export [-format csv] [-country hr] [-status active] [-minor true] [-elderly false] [-min-age 10] [-max-age 20]
+[-from 2020-01-01] [-to 2020-12-31] [-o persons.csv]
+
+Writes the normalized persons to the file or to stdout. Formats are csv, geojson (a point per person at the
+place of disappearance) and counties (a point per county with the number of persons).
+*/
+func export(db *gorm.DB, args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "csv", "csv, geojson or counties")
+	country := flags.String("country", "", "country code (hr, ro)")
+	status := flags.String("status", "", "active (still on the official sites) or removed")
+	minor := flags.String("minor", "", "only minors (true) or only adults (false) at the time of disappearance")
+	elderly := flags.String("elderly", "", "only elderly (true) or only non elderly (false) at the time of disappearance")
+	minAge := flags.Int("min-age", -1, "minimum current age")
+	maxAge := flags.Int("max-age", -1, "maximum current age")
+	from := flags.String("from", "", "disappeared on or after the date (YYYY-MM-DD)")
+	to := flags.String("to", "", "disappeared on or before the date (YYYY-MM-DD)")
+	output := flags.String("o", "", "output file, stdout if empty")
+	flags.Parse(args)
+
+	filter := persons.Filter{
+		Country:         *country,
+		Status:          *status,
+		Minor:           optionalBoolFlag("minor", *minor),
+		Elderly:         optionalBoolFlag("elderly", *elderly),
+		DisappearedFrom: optionalDateFlag("from", *from),
+		DisappearedTo:   optionalDateFlag("to", *to),
+	}
+
+	if *minAge >= 0 {
+		filter.MinAge = minAge
+	}
+
+	if *maxAge >= 0 {
+		filter.MaxAge = maxAge
+	}
+
+	var write func(w io.Writer, persons []persons.Person, now time.Time) error
+	switch *format {
+	case "csv":
+		write = persons.WriteCSV
+	case "geojson":
+		write = persons.WriteGeoJSON
+		filter.Located = true
+	case "counties":
+		write = persons.WriteCountiesGeoJSON
+		filter.Located = true
+	default:
+		log.Fatalf("unknown export format %s\n", *format)
+	}
+
+	found, err := persons.Find(db, filter)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			log.Fatalln(err)
+		}
+		defer out.Close()
+	}
+
+	if err := write(out, found, time.Now()); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func optionalBoolFlag(name, value string) *bool {
+	if value == "" {
+		return nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("-%s must be true or false\n", name)
+	}
+
+	return &b
+}
+
+func optionalDateFlag(name, value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		log.Fatalf("-%s must be a date (YYYY-MM-DD)\n", name)
+	}
+
+	return &t
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"missing-persons-scrapper/pkg/geocode"
+)
+
+/*
+*
+gazetteer HR.txt RO.txt
+
+Loads GeoNames country extracts (https://download.geonames.org/export/dump/) into the gazetteer. Run
+normalize afterwards to geocode the persons again.
+*/
+func gazetteer(db *gorm.DB, files []string) {
+	if len(files) == 0 {
+		log.Fatalln("usage: gazetteer <geonames file>...")
+	}
+
+	for _, f := range files {
+		count, err := geocode.LoadFile(db, f)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		fmt.Printf("Loaded %d places from %s\n", count, f)
+	}
+}
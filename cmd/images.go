@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"missing-persons-scrapper/pkg/derivatives"
+	"missing-persons-scrapper/pkg/imagestore"
+)
+
+func imageStore() imagestore.Store {
+	store, err := imagestore.FromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return store
+}
+
+func generator(db *gorm.DB, images imagestore.Store) *derivatives.Generator {
+	sizes, err := derivatives.SizesFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return derivatives.NewGenerator(db, images, sizes)
+}
+
+/*
+*
+images migrate
+images derivatives
+images phash
+
+migrate moves the images that are still kept in the database to the image store. derivatives generates the
+sizes (IMAGE_SIZES) of every current image that does not have them yet, scraping does it after every run.
+phash computes the perceptual hashes of the images saved before images were hashed, run normalize
+afterwards to copy them to the persons.
+*/
+func images(db *gorm.DB, args []string) {
+	if len(args) != 1 {
+		log.Fatalln("usage: images migrate|derivatives|phash")
+	}
+
+	switch args[0] {
+	case "migrate":
+		moveImages(db)
+	case "derivatives":
+		generateDerivatives(db)
+	case "phash":
+		hashImages(db)
+	default:
+		log.Fatalln("usage: images migrate|derivatives|phash")
+	}
+}
+
+func moveImages(db *gorm.DB) {
+	images := imageStore()
+	for _, c := range countries(db, images) {
+		moved, err := c.store.MoveBlobs(images.Put)
+		if err != nil {
+			log.Fatalln(fmt.Errorf("%s: %w", c.code, err))
+		}
+
+		fmt.Printf("Moved %d images of %s\n", moved, c.code)
+	}
+}
+
+func hashImages(db *gorm.DB) {
+	images := imageStore()
+	for _, c := range countries(db, images) {
+		hashed, err := c.store.HashImages(images.Get)
+		if err != nil {
+			log.Fatalln(fmt.Errorf("%s: %w", c.code, err))
+		}
+
+		fmt.Printf("Hashed %d images of %s\n", hashed, c.code)
+	}
+}
+
+func generateDerivatives(db *gorm.DB) {
+	images := imageStore()
+	g := generator(db, images)
+	for _, c := range countries(db, images) {
+		hashes, err := c.source.ImageHashes()
+		if err != nil {
+			log.Fatalln(fmt.Errorf("%s: %w", c.code, err))
+		}
+
+		keys := make([]string, 0, len(hashes))
+		for _, h := range hashes {
+			keys = append(keys, h)
+		}
+
+		generated, err := g.Generate(keys)
+		if err != nil {
+			log.Fatalln(fmt.Errorf("%s: %w", c.code, err))
+		}
+
+		fmt.Printf("Generated %d image derivatives of %s\n", generated, c.code)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"missing-persons-scrapper/pkg/matching"
+	"strconv"
+)
+
+func match(db *gorm.DB) {
+	count, err := matching.Run(db)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Printf("Found %d possible duplicate persons\n", count)
+}
+
+/*
+*
+links [list] [-status candidate]
+links confirm <id>
+links reject <id>
+*/
+func links(db *gorm.DB, args []string) {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	switch args[0] {
+	case "list":
+		flags := flag.NewFlagSet("links list", flag.ExitOnError)
+		status := flags.String("status", matching.StatusCandidate, "candidate, confirmed or rejected; empty for every link")
+		flags.Parse(args[1:])
+
+		found, err := matching.List(db, *status)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		for _, l := range found {
+			fmt.Printf("%d\t%.2f\t%s\t%s:%s\t%s:%s\t%s\n", l.ID, l.Score, l.Status, l.CountryA, l.ItemIDA, l.CountryB, l.ItemIDB, l.Reasons)
+		}
+	case "confirm", "reject":
+		if len(args) != 2 {
+			log.Fatalf("usage: links %s <id>\n", args[0])
+		}
+
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			log.Fatalln("link id must be a number")
+		}
+
+		review := matching.Confirm
+		if args[0] == "reject" {
+			review = matching.Reject
+		}
+
+		link, err := review(db, id)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		fmt.Printf("Link %d is %s\n", link.ID, link.Status)
+	default:
+		log.Fatalf("unknown links command %s\n", args[0])
+	}
+}
@@ -1,19 +1,106 @@
 package main
 
 import (
+	"fmt"
 	"github.com/joho/godotenv"
+	"gorm.io/gorm"
 	"log"
-	"missing-persons-scrapper/pkg/countries/croatia"
-	"missing-persons-scrapper/pkg/countries/romania"
+	"missing-persons-scrapper/pkg/geocode"
+	"missing-persons-scrapper/pkg/imagestore"
+	"missing-persons-scrapper/pkg/persons"
+	"missing-persons-scrapper/pkg/scraper"
 	"missing-persons-scrapper/pkg/storage"
+	"os"
 )
 
+/*
+*
+Without arguments or with scrape the program scrapes every country and normalizes the scrapped data. Other
+commands:
+
+	serve      starts the API server (feeds, images, persons)
+	normalize  normalizes the scrapped data again without scrapping
+	export     writes the normalized persons as CSV or GeoJSON
+	match      finds persons that are most likely the same person
+	links      lists, confirms and rejects the found duplicates
+	gazetteer  loads GeoNames country extracts used for geocoding places
+	migrate    applies (up), reverts (down) or lists (status) the database migrations, or reverts the
+	           migrations of the tables of a single country (down-tables)
+	images     moves the images kept in the database to the image store (migrate), generates the
+	           resized copies of the images (derivatives) or hashes the images saved before (phash)
+	similar    lists the persons whose photos are most similar to an image file
+	poster     writes a printable PDF poster of a person
+	reparse    parses the archived pages again with the current parsers, without fetching them
+	reprocess  normalizes the scrapped data again if the normalization changed and reports what changed
+*/
 func main() {
+	// a mistyped command does not start a scrape
+	if !known(command()) {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
 	loadEnv()
-	storage.Connect()
-	migrate()
+	db, err := storage.Connect()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if command() == "migrate" {
+		migrate(db, os.Args[2:])
+		return
+	}
+
+	checkSchema(db)
 
-	run()
+	switch command() {
+	case "serve":
+		serve(db)
+	case "normalize":
+		normalize(db)
+	case "export":
+		export(db, os.Args[2:])
+	case "match":
+		match(db)
+	case "links":
+		links(db, os.Args[2:])
+	case "gazetteer":
+		gazetteer(db, os.Args[2:])
+	case "images":
+		images(db, os.Args[2:])
+	case "similar":
+		similar(db, os.Args[2:])
+	case "poster":
+		posterCommand(db, os.Args[2:])
+	case "reparse":
+		reparse(db, os.Args[2:])
+	case "reprocess":
+		reprocess(db, os.Args[2:])
+	case "", "scrape":
+		run(db)
+		generateDerivatives(db)
+		normalize(db)
+		match(db)
+	}
+}
+
+const usage = "usage: scrape|serve|normalize|export|match|links|gazetteer|migrate|images|similar|poster|reparse|reprocess [arguments]"
+
+func known(command string) bool {
+	switch command {
+	case "", "scrape", "serve", "normalize", "export", "match", "links", "gazetteer", "migrate", "images", "similar", "poster", "reparse", "reprocess":
+		return true
+	}
+
+	return false
+}
+
+func command() string {
+	if len(os.Args) < 2 {
+		return ""
+	}
+
+	return os.Args[1]
 }
 
 func loadEnv() {
@@ -24,20 +111,34 @@ func loadEnv() {
 	}
 }
 
-func run() {
+func run(db *gorm.DB) {
+	images := imageStore()
 	p := newParallel()
-	p.add(func() { croatia.Start() })
-	p.add(func() { romania.Start() })
+	for _, c := range countries(db, images) {
+		s := c.scraper(dependencies(c.store, images))
+		p.add(func() { s.Run() })
+	}
 
 	p.wait()
 }
 
-func migrate() {
-	if err := croatia.Migrate(); err != nil {
+func dependencies(store storage.Store, images imagestore.Store) scraper.Dependencies {
+	deps, err := scraper.NewDependencies(store, images)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return deps
+}
+
+func normalize(db *gorm.DB) {
+	images := imageStore()
+	index, err := geocode.LoadIndex(db)
+	if err != nil {
 		log.Fatalln(err)
 	}
 
-	if err := romania.Migrate(); err != nil {
+	if err := persons.Normalize(db, index, personSources(countries(db, images))...); err != nil {
 		log.Fatalln(err)
 	}
 }
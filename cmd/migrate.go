@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"missing-persons-scrapper/pkg/engine"
+	"missing-persons-scrapper/pkg/migrations"
+	"strconv"
+)
+
+/*
+*
+migrate [up]
+migrate down [steps]
+migrate down-tables <raw table> [steps]
+migrate status
+
+up also creates the tables of the countries defined in COUNTRIES_DIR and applies the table migrations every
+country's tables are missing, down-tables reverts the ones of the tables of a single country.
+*/
+func migrate(db *gorm.DB, args []string) {
+	if len(args) == 0 {
+		args = []string{"up"}
+	}
+
+	switch args[0] {
+	case "up":
+		count, err := migrations.Up(db)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		// the countries defined in COUNTRIES_DIR get their tables
+		for _, def := range definitions() {
+			n, err := migrations.UpTables(db, def.Tables())
+			count += n
+			if err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		fmt.Printf("Applied %d migrations\n", count)
+	case "down":
+		count, err := migrations.Down(db, steps(args[1:]))
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		fmt.Printf("Reverted %d migrations\n", count)
+	case "down-tables":
+		if len(args) < 2 {
+			log.Fatalln("usage: migrate down-tables <raw table> [steps]")
+		}
+
+		count, err := migrations.DownTables(db, args[1], steps(args[2:]))
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		fmt.Printf("Reverted %d table migrations of %s\n", count, args[1])
+	case "status":
+		states, err := migrations.Status(db)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		printStates("", states)
+
+		tables, err := migrations.TablesStatus(db)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		for _, t := range tables {
+			printStates(fmt.Sprintf("%s/%s\t", t.Tables.Raw, t.Tables.Images), t.States)
+		}
+	default:
+		log.Fatalf("unknown migrate command %s\n", args[0])
+	}
+}
+
+// the number of migrations to revert, 1 if not given
+func steps(args []string) int {
+	if len(args) == 0 {
+		return 1
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		log.Fatalln("steps must be a positive number")
+	}
+
+	return n
+}
+
+func printStates(prefix string, states []migrations.State) {
+	for _, s := range states {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+
+		fmt.Printf("%s%04d\t%s\t%s\n", prefix, s.Version, s.Name, applied)
+	}
+}
+
+/*
+*
+Every other command refuses to run against a schema that does not match the migrations of this program or
+that has no tables for a defined country, only migrate changes the schema.
+*/
+func checkSchema(db *gorm.DB) {
+	if err := migrations.Check(db); err != nil {
+		log.Fatalln(err)
+	}
+
+	for _, def := range definitions() {
+		if err := migrations.CheckTables(db, def.Tables()); err != nil {
+			log.Fatalln(fmt.Errorf("country %s: %w", def.Country, err))
+		}
+	}
+}
+
+func definitions() []*engine.Definition {
+	defs, err := engine.FromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return defs
+}
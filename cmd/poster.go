@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"missing-persons-scrapper/pkg/persons"
+	"missing-persons-scrapper/pkg/poster"
+	"os"
+	"time"
+)
+
+/*
+*
+poster [-lang hr|ro|en] <country> <website id> <file.pdf>
+
+Writes a printable one page poster of the person, in the language of the country's site by default.
+*/
+func posterCommand(db *gorm.DB, args []string) {
+	flags := flag.NewFlagSet("poster", flag.ExitOnError)
+	language := flags.String("lang", "", "hr, ro or en; the language of the country's site by default")
+	flags.Parse(args)
+
+	if flags.NArg() != 3 {
+		log.Fatalln("usage: poster [-lang hr|ro|en] <country> <website id> <file.pdf>")
+	}
+
+	country, itemID, path := flags.Arg(0), flags.Arg(1), flags.Arg(2)
+	if *language == "" {
+		*language = poster.DefaultLanguage(country)
+	}
+
+	template, ok := poster.TemplateFor(*language)
+	if !ok {
+		log.Fatalf("unknown language %s\n", *language)
+	}
+
+	var src countrySource
+	for _, c := range countries(db, imageStore()) {
+		if c.code == country {
+			src = c.source
+		}
+	}
+
+	if src == nil {
+		log.Fatalf("unknown country %s\n", country)
+	}
+
+	person, err := persons.FindByItem(db, country, itemID)
+	if err != nil {
+		log.Fatalln(fmt.Errorf("%s %s: %w", country, itemID, err))
+	}
+
+	// a person without an image gets a poster without a photo
+	photo, _, err := src.Image(itemID)
+	if err != nil {
+		log.Println(fmt.Errorf("poster without a photo: %w", err))
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+
+	if err := poster.Render(f, poster.Poster{Person: person, Photo: photo, Template: template, Now: time.Now()}); err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Printf("Wrote the poster of %s %s to %s\n", country, itemID, path)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"gorm.io/gorm"
+	"log"
+	"missing-persons-scrapper/pkg/httpClient"
+	"missing-persons-scrapper/pkg/scraper"
+	"missing-persons-scrapper/pkg/warc"
+	"os"
+)
+
+/*
+*
+reparse [-archive dir] [-run id] [-cache dir] [country...]
+
+Parses the saved pages again with the current parsers and normalizes the persons again, nothing is fetched
+from the official sites. The pages are read from the WARC files in -archive (ARCHIVE_DIR by default), only
+the files of a single run with -run, or from the HTTP cache in -cache. Without country codes every country
+is parsed again.
+*/
+func reparse(db *gorm.DB, args []string) {
+	flags := flag.NewFlagSet("reparse", flag.ExitOnError)
+	archiveDir := flags.String("archive", os.Getenv("ARCHIVE_DIR"), "the directory of the WARC files")
+	runID := flags.Int("run", 0, "only the pages archived by this run, 0 for the newest page of every run")
+	cacheDir := flags.String("cache", "", "read the pages from this HTTP cache directory instead of the archive")
+	flags.Parse(args)
+
+	if *archiveDir == "" && *cacheDir == "" {
+		log.Fatalln("usage: reparse [-archive dir] [-run id] [-cache dir] [country...]")
+	}
+
+	images := imageStore()
+	all := countries(db, images)
+
+	codes := flags.Args()
+	if len(codes) == 0 {
+		for _, c := range all {
+			codes = append(codes, c.code)
+		}
+	}
+
+	scrapers := make([]scraper.Scraper, 0, len(codes))
+	for _, code := range codes {
+		found := false
+		for _, c := range all {
+			if c.code == code {
+				deps := dependencies(c.store, images).Reparse(replayFetcher(code, *archiveDir, *runID, *cacheDir))
+				scrapers = append(scrapers, c.scraper(deps))
+				found = true
+			}
+		}
+
+		if !found {
+			log.Fatalf("unknown country %s\n", code)
+		}
+	}
+
+	p := newParallel()
+	for _, s := range scrapers {
+		p.add(func() {
+			run := s.Run()
+			log.Printf("%s: parsed %d pages again, %d updated, %d unchanged, %d failed\n", s.Country(), run.Seen, run.Updated, run.Unchanged, run.Failed)
+		})
+	}
+
+	p.wait()
+
+	normalize(db)
+}
+
+func replayFetcher(country, archiveDir string, runID int, cacheDir string) httpClient.Fetcher {
+	if cacheDir != "" {
+		cache, err := httpClient.NewCache(cacheDir)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		return httpClient.NewReplayFetcher(cache)
+	}
+
+	archive := &warc.Archive{Dir: archiveDir}
+	files, err := archive.Files(country, runID)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if len(files) == 0 {
+		log.Fatalf("no archived pages of %s in %s\n", country, archiveDir)
+	}
+
+	fetcher, err := warc.NewReplayFetcher(files)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	return fetcher
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"missing-persons-scrapper/pkg/geocode"
+	"missing-persons-scrapper/pkg/persons"
+)
+
+/*
+*
+reprocess [-force]
+
+Normalizes every stored raw row again without fetching anything, when persons were normalized by another
+version of the normalization (normalize.Version), and reports how many persons changed. -force normalizes
+them again even if every person is up to date.
+*/
+func reprocess(db *gorm.DB, args []string) {
+	flags := flag.NewFlagSet("reprocess", flag.ExitOnError)
+	force := flags.Bool("force", false, "normalize every person again even if none is outdated")
+	flags.Parse(args)
+
+	outdated, err := persons.Outdated(db)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if outdated == 0 && !*force {
+		fmt.Println("every person is normalized by the current version, nothing to reprocess")
+		return
+	}
+
+	images := imageStore()
+	index, err := geocode.LoadIndex(db)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	result, err := persons.Reprocess(db, index, personSources(countries(db, images))...)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Printf("%d persons were normalized by another version\n", outdated)
+	for _, r := range result {
+		fmt.Printf("%s: %d normalized, %d changed, %d new\n", r.Country, r.Normalized, r.Changed, r.Created)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"gorm.io/gorm"
+	"log"
+	"missing-persons-scrapper/pkg/api"
+	"net/http"
+	"os"
+)
+
+func serve(db *gorm.DB) {
+	addr := os.Getenv("API_ADDR")
+	if addr == "" {
+		addr = ":8080"
+	}
+
+	// used to create absolute links in the feeds
+	baseURL := os.Getenv("API_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost" + addr
+	}
+
+	images := imageStore()
+	server := api.NewServer(db, baseURL, generator(db, images), apiSources(countries(db, images))...)
+
+	log.Printf("API listening on %s\n", addr)
+	if err := http.ListenAndServe(addr, server); err != nil {
+		log.Fatalln(err)
+	}
+}
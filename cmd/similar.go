@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"missing-persons-scrapper/pkg/imagefile"
+	"missing-persons-scrapper/pkg/persons"
+	"os"
+)
+
+/*
+*
+similar [-max-distance 10] [-limit 20] <image file>
+
+Lists the persons whose photos are most similar to the image, by the distance of the perceptual hashes.
+*/
+func similar(db *gorm.DB, args []string) {
+	flags := flag.NewFlagSet("similar", flag.ExitOnError)
+	maxDistance := flags.Int("max-distance", 10, "the most bits the perceptual hashes can differ in (0-64)")
+	limit := flags.Int("limit", 20, "the number of persons to list, 0 for every similar photo")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatalln("usage: similar [-max-distance 10] [-limit 20] <image file>")
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	hash, err := imagefile.HashData(data)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	found, err := persons.FindSimilarPhotos(db, hash, *maxDistance, *limit)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	for _, f := range found {
+		fmt.Printf("%d\t%s\t%s\t%s %s\t%s\n", f.Distance, f.Person.Country, f.Person.ItemID, f.Person.Name, f.Person.LastName, f.Person.SourceURL)
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"missing-persons-scrapper/pkg/feed"
+	"net/http"
+)
+
+// The "all" country merges the feeds of every source.
+const allCountries = "all"
+
+/*
+*
+GET /feeds/{country}/{kind}/{format}
+
+country is a source country code or "all", kind is "new" or "removed" and format is "atom" or "rss".
+*/
+func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
+	country := r.PathValue("country")
+	kind := r.PathValue("kind")
+	format := r.PathValue("format")
+
+	if kind != feed.KindAdded && kind != feed.KindRemoved {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown feed kind %s", kind))
+		return
+	}
+
+	var write func(w io.Writer, f feed.Feed) error
+	switch format {
+	case "atom":
+		write = feed.WriteAtom
+	case "rss":
+		write = feed.WriteRSS
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown feed format %s", format))
+		return
+	}
+
+	sources := make([]Source, 0)
+	if country == allCountries {
+		for _, src := range s.sources {
+			sources = append(sources, src)
+		}
+	} else if src, ok := s.source(country); ok {
+		sources = append(sources, src)
+	} else {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown country %s", country))
+		return
+	}
+
+	lists := make([][]feed.Entry, 0, len(sources))
+	for _, src := range sources {
+		var entries []feed.Entry
+		var err error
+		if kind == feed.KindAdded {
+			entries, err = src.Added(feedLimit)
+		} else {
+			entries, err = src.Removed(feedLimit)
+		}
+
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("failed getting %s feed for %s: %w", kind, src.Country(), err))
+			return
+		}
+
+		// a person without an image has no enclosure, the image would not be found
+		for i := range entries {
+			if entries[i].ImageExtension != "" {
+				entries[i].ImageURL = s.url("/images/%s/%s", entries[i].Country, entries[i].ItemID)
+			}
+		}
+
+		lists = append(lists, entries)
+	}
+
+	f := feed.NewFeed(
+		feed.EntryID(country, kind),
+		feedTitle(country, kind),
+		s.url("/feeds/%s/%s/%s", country, kind, format),
+		feed.Merge(feedLimit, lists...),
+	)
+
+	w.Header().Set("Content-Type", fmt.Sprintf("application/%s+xml; charset=utf-8", format))
+	if err := write(w, f); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func feedTitle(country, kind string) string {
+	subject := "Newly reported missing persons"
+	if kind == feed.KindRemoved {
+		subject = "Missing persons removed from the official sites"
+	}
+
+	if country == allCountries {
+		return subject
+	}
+
+	return fmt.Sprintf("%s (%s)", subject, country)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"mime"
+	"missing-persons-scrapper/pkg/derivatives"
+	"missing-persons-scrapper/pkg/imagestore"
+	"net/http"
+)
+
+// GET /images/{country}/{itemID}
+func (s *Server) handleImage(w http.ResponseWriter, r *http.Request) {
+	src, ok := s.source(r.PathValue("country"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown country %s", r.PathValue("country")))
+		return
+	}
+
+	blob, extension, err := src.Image(r.PathValue("itemID"))
+	if notFound(err) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	contentType := mime.TypeByExtension("." + extension)
+	if contentType == "" {
+		contentType = http.DetectContentType(blob)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(blob)
+}
+
+// GET /images/{country}/{itemID}/{size}, size is one of the configured names (IMAGE_SIZES)
+func (s *Server) handleImageSize(w http.ResponseWriter, r *http.Request) {
+	src, ok := s.source(r.PathValue("country"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown country %s", r.PathValue("country")))
+		return
+	}
+
+	hash, err := src.ImageHash(r.PathValue("itemID"))
+	if notFound(err) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// an image saved before the image store existed has no derivatives until it is moved
+	if hash == "" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("image of %s is not in the image store", r.PathValue("itemID")))
+		return
+	}
+
+	blob, contentType, err := s.derivatives.Get(hash, r.PathValue("size"))
+	if notFound(err) || errors.Is(err, derivatives.ErrUnknownSize) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(blob)
+}
+
+func notFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, imagestore.ErrNotFound)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"missing-persons-scrapper/pkg/matching"
+	"net/http"
+	"strconv"
+)
+
+// A confirmed link as seen from one of the two persons.
+type linkedPerson struct {
+	LinkID  int     `json:"link_id"`
+	Country string  `json:"country"`
+	ItemID  string  `json:"item_id"`
+	Score   float64 `json:"score"`
+}
+
+// GET /links?status=candidate
+func (s *Server) handleLinks(w http.ResponseWriter, r *http.Request) {
+	links, err := matching.List(s.db, r.URL.Query().Get("status"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, links)
+}
+
+// POST /links/{id}/confirm
+func (s *Server) handleConfirmLink(w http.ResponseWriter, r *http.Request) {
+	s.reviewLink(w, r, matching.Confirm)
+}
+
+// POST /links/{id}/reject
+func (s *Server) handleRejectLink(w http.ResponseWriter, r *http.Request) {
+	s.reviewLink(w, r, matching.Reject)
+}
+
+func (s *Server) reviewLink(w http.ResponseWriter, r *http.Request, review func(db *gorm.DB, id int) (matching.Link, error)) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "id must be a number", http.StatusBadRequest)
+		return
+	}
+
+	link, err := review(s.db, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("link %d does not exist", id))
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, link)
+}
+
+func linkedPersons(country, itemID string, links []matching.Link) []linkedPerson {
+	linked := make([]linkedPerson, len(links))
+	for i, l := range links {
+		c, id := l.Other(country, itemID)
+		linked[i] = linkedPerson{LinkID: l.ID, Country: c, ItemID: id, Score: l.Score}
+	}
+
+	return linked
+}
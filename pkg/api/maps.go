@@ -0,0 +1,51 @@
+package api
+
+import (
+	"io"
+	"missing-persons-scrapper/pkg/persons"
+	"net/http"
+	"time"
+)
+
+/*
+*
+GET /persons.geojson
+
+The persons with a geocoded place of disappearance as GeoJSON points. Takes the same filters as /persons,
+without a limit.
+*/
+func (s *Server) handlePersonsGeoJSON(w http.ResponseWriter, r *http.Request) {
+	s.writeGeoJSON(w, r, persons.WriteGeoJSON)
+}
+
+/*
+*
+GET /counties.geojson
+
+The number of persons per county of disappearance as GeoJSON points, for heatmaps.
+*/
+func (s *Server) handleCountiesGeoJSON(w http.ResponseWriter, r *http.Request) {
+	s.writeGeoJSON(w, r, persons.WriteCountiesGeoJSON)
+}
+
+func (s *Server) writeGeoJSON(w http.ResponseWriter, r *http.Request, write func(w io.Writer, persons []persons.Person, now time.Time) error) {
+	filter, err := personsFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter.Limit = 0
+	filter.Located = true
+
+	found, err := persons.Find(s.db, filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	if err := write(w, found, time.Now()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"fmt"
+	"missing-persons-scrapper/pkg/matching"
+	"missing-persons-scrapper/pkg/normalize"
+	"missing-persons-scrapper/pkg/persons"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const maxPersonsLimit = 1000
+
+type personResponse struct {
+	Country   string     `json:"country"`
+	ItemID    string     `json:"item_id"`
+	SourceURL string     `json:"source_url"`
+	ImageURL  string     `json:"image_url"`
+	FirstSeen time.Time  `json:"first_seen"`
+	RemovedAt *time.Time `json:"removed_at"`
+
+	normalize.Person
+	normalize.Ages
+
+	POBPlace persons.Place `json:"pob_place"`
+	PODPlace persons.Place `json:"pod_place"`
+
+	// persons on this or another site that were confirmed to be the same person
+	Links []linkedPerson `json:"links"`
+}
+
+/*
+*
+GET /persons
+
+Query parameters: country, status ("active" or "removed"), minor, elderly, min_age, max_age (current age),
+from, to (date of disappearance, YYYY-MM-DD), limit, offset and format ("json" or "csv").
+*/
+func (s *Server) handlePersons(w http.ResponseWriter, r *http.Request) {
+	filter, err := personsFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	found, err := persons.Find(s.db, filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	now := time.Now()
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		if err := persons.WriteCSV(w, found, now); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	links, err := matching.ConfirmedLinks(s.db)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response := make([]personResponse, len(found))
+	for i, p := range found {
+		response[i] = personResponse{
+			Country:   p.Country,
+			ItemID:    p.ItemID,
+			SourceURL: p.SourceURL,
+			ImageURL:  s.url("/images/%s/%s", p.Country, p.ItemID),
+			FirstSeen: p.FirstSeen,
+			RemovedAt: p.RemovedAt,
+			Person:    p.Normalized(),
+			Ages:      p.Ages(now),
+			POBPlace:  p.POBPlace,
+			PODPlace:  p.PODPlace,
+			Links:     linkedPersons(p.Country, p.ItemID, links[matching.PersonKey(p.Country, p.ItemID)]),
+		}
+	}
+
+	writeJSON(w, response)
+}
+
+func personsFilter(query url.Values) (persons.Filter, error) {
+	filter := persons.Filter{Country: query.Get("country"), Limit: maxPersonsLimit}
+
+	var err error
+	if filter.Minor, err = optionalBool(query, "minor"); err != nil {
+		return filter, err
+	}
+
+	if filter.Elderly, err = optionalBool(query, "elderly"); err != nil {
+		return filter, err
+	}
+
+	if filter.MinAge, err = optionalInt(query, "min_age"); err != nil {
+		return filter, err
+	}
+
+	if filter.MaxAge, err = optionalInt(query, "max_age"); err != nil {
+		return filter, err
+	}
+
+	filter.Status = query.Get("status")
+	if filter.Status != "" && filter.Status != persons.StatusActive && filter.Status != persons.StatusRemoved {
+		return filter, fmt.Errorf("status must be %s or %s", persons.StatusActive, persons.StatusRemoved)
+	}
+
+	if filter.DisappearedFrom, err = optionalDate(query, "from"); err != nil {
+		return filter, err
+	}
+
+	if filter.DisappearedTo, err = optionalDate(query, "to"); err != nil {
+		return filter, err
+	}
+
+	limit, err := optionalInt(query, "limit")
+	if err != nil {
+		return filter, err
+	}
+	if limit != nil && *limit > 0 && *limit < maxPersonsLimit {
+		filter.Limit = *limit
+	}
+
+	offset, err := optionalInt(query, "offset")
+	if err != nil {
+		return filter, err
+	}
+	if offset != nil {
+		filter.Offset = *offset
+	}
+
+	return filter, nil
+}
+
+func optionalBool(query url.Values, name string) (*bool, error) {
+	if !query.Has(name) {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(query.Get(name))
+	if err != nil {
+		return nil, fmt.Errorf("%s must be true or false", name)
+	}
+
+	return &b, nil
+}
+
+func optionalInt(query url.Values, name string) (*int, error) {
+	if !query.Has(name) {
+		return nil, nil
+	}
+
+	i, err := strconv.Atoi(query.Get(name))
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", name)
+	}
+
+	return &i, nil
+}
+
+func optionalDate(query url.Values, name string) (*time.Time, error) {
+	if !query.Has(name) {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, query.Get(name))
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a date (YYYY-MM-DD)", name)
+	}
+
+	return &t, nil
+}
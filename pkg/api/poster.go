@@ -0,0 +1,64 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"missing-persons-scrapper/pkg/persons"
+	"missing-persons-scrapper/pkg/poster"
+	"net/http"
+	"time"
+)
+
+/*
+*
+GET /persons/{country}/{itemID}/poster
+
+Query parameters: lang ("hr", "ro" or "en"), the language of the country's site by default.
+*/
+func (s *Server) handlePoster(w http.ResponseWriter, r *http.Request) {
+	country, itemID := r.PathValue("country"), r.PathValue("itemID")
+	src, ok := s.source(country)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown country %s", country))
+		return
+	}
+
+	language := r.URL.Query().Get("lang")
+	if language == "" {
+		language = poster.DefaultLanguage(country)
+	}
+
+	template, ok := poster.TemplateFor(language)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown language %s", language), http.StatusBadRequest)
+		return
+	}
+
+	person, err := persons.FindByItem(s.db, country, itemID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// a person without an image gets a poster without a photo
+	photo, _, err := src.Image(itemID)
+	if err != nil && !notFound(err) {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	buff := &bytes.Buffer{}
+	if err := poster.Render(buff, poster.Poster{Person: person, Photo: photo, Template: template, Now: time.Now()}); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s-%s-%s.pdf"`, country, itemID, language))
+	w.Write(buff.Bytes())
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"missing-persons-scrapper/pkg/feed"
+	"net/http"
+	"strings"
+)
+
+// the number of entries in a single feed
+const feedLimit = 50
+
+// A country whose scrapped persons the API exposes.
+type Source interface {
+	Country() string
+	Added(limit int) ([]feed.Entry, error)
+	Removed(limit int) ([]feed.Entry, error)
+	Image(itemID string) ([]byte, string, error)
+	ImageHash(itemID string) (string, error)
+}
+
+// Resized copies of the images by the hash of the image and the name of the size.
+type Derivatives interface {
+	Get(sourceHash, size string) ([]byte, string, error)
+}
+
+type Server struct {
+	// the database of the persons and the links
+	db          *gorm.DB
+	baseURL     string
+	sources     map[string]Source
+	derivatives Derivatives
+	mux         *http.ServeMux
+}
+
+func NewServer(db *gorm.DB, baseURL string, derivatives Derivatives, sources ...Source) *Server {
+	s := &Server{
+		db:          db,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		sources:     make(map[string]Source),
+		derivatives: derivatives,
+		mux:         http.NewServeMux(),
+	}
+
+	for _, src := range sources {
+		s.sources[src.Country()] = src
+	}
+
+	s.routes()
+
+	return s
+}
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /feeds/{country}/{kind}/{format}", s.handleFeed)
+	s.mux.HandleFunc("GET /images/{country}/{itemID}", s.handleImage)
+	s.mux.HandleFunc("GET /images/{country}/{itemID}/{size}", s.handleImageSize)
+	s.mux.HandleFunc("POST /images/similar", s.handleSimilarImages)
+	s.mux.HandleFunc("GET /persons", s.handlePersons)
+	s.mux.HandleFunc("GET /persons/{country}/{itemID}/poster", s.handlePoster)
+	s.mux.HandleFunc("GET /persons.geojson", s.handlePersonsGeoJSON)
+	s.mux.HandleFunc("GET /counties.geojson", s.handleCountiesGeoJSON)
+	s.mux.HandleFunc("GET /links", s.handleLinks)
+	s.mux.HandleFunc("POST /links/{id}/confirm", s.handleConfirmLink)
+	s.mux.HandleFunc("POST /links/{id}/reject", s.handleRejectLink)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) source(country string) (Source, bool) {
+	src, ok := s.sources[country]
+	return src, ok
+}
+
+func (s *Server) url(format string, args ...any) string {
+	return s.baseURL + fmt.Sprintf(format, args...)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println(err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		log.Println(err)
+	}
+
+	http.Error(w, http.StatusText(status), status)
+}
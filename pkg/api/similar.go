@@ -0,0 +1,86 @@
+package api
+
+import (
+	"io"
+	"missing-persons-scrapper/pkg/imagefile"
+	"missing-persons-scrapper/pkg/persons"
+	"net/http"
+)
+
+const (
+	// uploaded images larger than this are rejected
+	maxUploadSize = 10 << 20
+	// at most this many bits differ by default, photos further apart are rarely the same photo
+	defaultMaxDistance  = 10
+	defaultSimilarLimit = 20
+)
+
+type similarResponse struct {
+	Country   string `json:"country"`
+	ItemID    string `json:"item_id"`
+	Name      string `json:"name"`
+	LastName  string `json:"last_name"`
+	SourceURL string `json:"source_url"`
+	ImageURL  string `json:"image_url"`
+	Removed   bool   `json:"removed"`
+	// the number of different bits of the perceptual hashes (0-64), 0 is the same photo
+	Distance int `json:"distance"`
+}
+
+/*
+*
+POST /images/similar
+
+The body is the image. Query parameters: max_distance (0-64, 10 by default) and limit (20 by default).
+Returns the persons with the most similar photos first.
+*/
+func (s *Server) handleSimilarImages(w http.ResponseWriter, r *http.Request) {
+	maxDistance, limit := defaultMaxDistance, defaultSimilarLimit
+	if v, err := optionalInt(r.URL.Query(), "max_distance"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if v != nil {
+		maxDistance = *v
+	}
+
+	if v, err := optionalInt(r.URL.Query(), "limit"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if v != nil {
+		limit = *v
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxUploadSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	hash, err := imagefile.HashData(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	found, err := persons.FindSimilarPhotos(s.db, hash, maxDistance, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response := make([]similarResponse, len(found))
+	for i, f := range found {
+		response[i] = similarResponse{
+			Country:   f.Person.Country,
+			ItemID:    f.Person.ItemID,
+			Name:      f.Person.Name,
+			LastName:  f.Person.LastName,
+			SourceURL: f.Person.SourceURL,
+			ImageURL:  s.url("/images/%s/%s", f.Person.Country, f.Person.ItemID),
+			Removed:   f.Person.RemovedAt != nil,
+			Distance:  f.Distance,
+		}
+	}
+
+	writeJSON(w, response)
+}
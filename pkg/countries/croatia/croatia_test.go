@@ -4,16 +4,18 @@ import (
 	"fmt"
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
-	"log"
+	"missing-persons-scrapper/pkg/imagestore"
+	"missing-persons-scrapper/pkg/scraper"
 	"missing-persons-scrapper/pkg/storage"
 	"testing"
 )
 
-func loadEnv() {
+// The test runs against the database of ../../.env, without it the test is skipped.
+func loadEnv(t *testing.T) {
 	err := godotenv.Load("../../.env")
 
 	if err != nil {
-		log.Fatal(err)
+		t.Skip(err)
 	}
 }
 
@@ -27,8 +29,9 @@ The other important thing is that the number of raw data in the database is cons
 after multiple runs.
 */
 func TestRunning(t *testing.T) {
-	loadEnv()
-	storage.Connect()
+	loadEnv(t)
+	db, err := storage.Connect()
+	assert.Nil(t, err)
 	reset := []string{
 		"TRUNCATE table croatia_scrapped",
 		"TRUNCATE table croatia_images",
@@ -37,20 +40,24 @@ func TestRunning(t *testing.T) {
 	}
 
 	for _, r := range reset {
-		storage.DB.Exec(r)
+		db.Exec(r)
 	}
 
-	Start()
+	images, err := imagestore.NewFilesystem(t.TempDir())
+	assert.Nil(t, err)
+	deps, err := scraper.NewDependencies(NewStore(db), images)
+	assert.Nil(t, err)
+	NewScraper(deps).Run()
 
 	var scrappedDataCount int
-	res := storage.DB.Raw(fmt.Sprintf("SELECT COUNT(id) FROM %s", Croatia_Scrapper_Table)).Scan(&scrappedDataCount)
+	res := db.Raw(fmt.Sprintf("SELECT COUNT(id) FROM %s", Croatia_Scrapper_Table)).Scan(&scrappedDataCount)
 	assert.Nil(t, res.Error)
 	assert.Equal(t, scrappedDataCount, 2877)
 
 	var scrappedImageCount int
-	res = storage.DB.Raw(fmt.Sprintf("SELECT COUNT(id) FROM %s", Croatia_Images_Table)).Scan(&scrappedImageCount)
+	res = db.Raw(fmt.Sprintf("SELECT COUNT(id) FROM %s", Croatia_Images_Table)).Scan(&scrappedImageCount)
 	assert.Nil(t, res.Error)
 	assert.Equal(t, scrappedImageCount, 2863)
 
-	storage.Close()
+	assert.Nil(t, storage.Close(db))
 }
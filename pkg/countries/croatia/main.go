@@ -3,41 +3,63 @@ package croatia
 import (
 	"crypto/sha256"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/andybalholm/cascadia"
 	"golang.org/x/net/html"
-	"gorm.io/gorm"
-	"log"
 	"missing-persons-scrapper/pkg/htmlParser"
+	"missing-persons-scrapper/pkg/scraper"
 	"missing-persons-scrapper/pkg/storage"
 	"strings"
-	"sync"
+	"time"
 )
 
-func Start() {
+// Scrapes nestali.gov.hr
+type Scraper struct {
+	scraper.Dependencies
+	// what the pages of nestali.gov.hr are checked against
+	Expectations scraper.Expectations
+}
+
+func NewScraper(deps scraper.Dependencies) *Scraper {
+	return &Scraper{Dependencies: deps, Expectations: expectations}
+}
+
+func (s *Scraper) Country() string {
+	return Country
+}
+
+func (s *Scraper) Run() storage.Run {
 	letters := []string{"a", "b", "c", "č", "ć", "d", "đ", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p", "r", "s", "š", "t", "u", "v", "w", "x", "z", "ž"}
 
-	wg := &sync.WaitGroup{}
+	run := storage.Run{Country: Country, StartedAt: s.Clock()}
+	finishArchive := s.BeginRun(&run)
+	defer finishArchive()
+
+	images := make([]scraper.PendingImage, 0)
+	writer := s.Writer()
+	layout := scraper.NewLayout(s.Expectations)
+	// only a run that went through every letter and person can tell which persons were removed
+	complete := true
 
 	/**
 	https://nestali.gov.hr
 	Website navigation goes by letters (peoples names) and by that letter, by pages. So every letter can have multiple
 	people missing with around 15 per page.
 
-	This program goes through letters and run one goroutine per letter.
+	This program goes through letters one by one. It stops when the pages do not look as expected any more.
 	*/
+letters:
 	for _, letter := range letters {
-		wg.Add(1)
 		page := 1
-		defer wg.Done()
 
 		for {
 			// get the list of all persons on letter and page
 			// if it fails, continue on to the next one
-			list, err := getList(fmt.Sprintf("https://nestali.gov.hr/nestale-osobe-403/403?slovo=%s&page=%d", letter, page))
+			list, err := s.getList(fmt.Sprintf("https://nestali.gov.hr/nestale-osobe-403/403?slovo=%s&page=%d", letter, page))
 			if err != nil {
-				log.Println(fmt.Errorf("failed to get list: letter: %s, page: %d: %w", letter, page, err))
+				s.Logger.Println(fmt.Errorf("failed to get list: letter: %s, page: %d: %w", letter, page, err))
+				complete = false
+				run.Failed++
 				break
 			}
 
@@ -46,42 +68,62 @@ func Start() {
 			if len(list) == 0 {
 				break
 			}
+			layout.List(len(list))
 
 			for _, l := range list {
 				// get the name of the person so you could get the id (id is the website id)
 				name, err := htmlParser.Find(l, ".osoba-ime")
 				if err != nil {
-					log.Println(fmt.Errorf("failed to find person: letter: %s, page: %d: %w", letter, page, err))
+					s.Logger.Println(fmt.Errorf("failed to find person: letter: %s, page: %d: %w", letter, page, err))
+					complete = false
+					run.Failed++
 					break
 				}
 
 				href := htmlParser.Attr("href", name.Attr)
 				if href != "" {
-					s := strings.Split(href, "=")
-
-					personId := s[1]
-					tokens, image, err := getTokens(personId)
+					parts := strings.Split(href, "=")
 
+					personId := parts[1]
+					profile, err := htmlParser.GetPage(s.Fetcher, personURL(personId))
 					if err != nil {
-						log.Println(fmt.Errorf("failed getting tokens: letter: %s, page: %d: %s; -> %w", letter, page, personId, err))
+						s.Logger.Println(fmt.Errorf("failed getting tokens: letter: %s, page: %d: %s; -> %w", letter, page, personId, err))
+						complete = false
+						run.Failed++
 						break
 					}
 
-					uniqueIdentifier := createUniqueIdentifier(tokens)
-					if err := tryDbOperation(tokens, personId, uniqueIdentifier, image); err != nil {
-						log.Println(err)
-						break
+					seenAt := s.Clock()
+					if profile.Unchanged {
+						// the page is the same as on the last run, the person is only marked as seen
+						err = writer.AddSeen(personId, seenAt, func() error {
+							return s.process(writer, layout, personId, profile.Body, seenAt, &images)
+						})
+					} else {
+						err = s.process(writer, layout, personId, profile.Body, seenAt, &images)
+					}
+
+					// the writer counts the persons of a batch that failed
+					if err != nil {
+						s.Logger.Println(err)
+						complete = false
+					}
+
+					run.Seen++
+					if layout.Changed() {
+						complete = false
+						break letters
 					}
 				}
 			}
 
-			fmt.Printf("Finished letter %s; page %d\n", letter, page)
+			s.Logger.Printf("Finished letter %s; page %d\n", letter, page)
 
 			page += 1
 		}
 	}
 
-	wg.Wait()
+	return s.FinishRun(run, writer, layout, &images, complete)
 }
 
 func createUniqueIdentifier(tokens []string) string {
@@ -91,103 +133,54 @@ func createUniqueIdentifier(tokens []string) string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
-func updateRecord(id int, tokens []string, personId, uniqueIdentifier string, tx *gorm.DB) *gorm.DB {
-	b, _ := json.Marshal(tokens)
-	data := NewRawData(b, personId, uniqueIdentifier)
-	data.ID = id
-
-	return tx.Save(&data)
+func personURL(personId string) string {
+	return fmt.Sprintf("https://nestali.gov.hr/nestale-osobe-403/403?osoba_id=%s", personId)
 }
 
-func tryDbOperation(tokens []string, personId, uniqueIdentifier, image string) error {
-	if err := storage.DB.Transaction(func(tx *gorm.DB) error {
-		createPerson := func() RawData {
-			b, _ := json.Marshal(tokens)
-			return NewRawData(b, personId, uniqueIdentifier)
-		}
-
-		getImage := func() ([]byte, string, error) {
-			buff := strings.Split(image, ".")
-			if len(buff) != 2 {
-				return nil, "", errors.New("cannot extract image extension")
-			}
-
-			body, err := downloadImage(fmt.Sprintf("https://nestali.gov.hr%s", image))
-			return body, buff[1], err
-		}
-
-		getCurrentPersonData := func() (err error, id int, rowsAffected int64) {
-			var p RawData
-			res := tx.Where("unique_identifier = ?", uniqueIdentifier).Select("id").First(&p)
-
-			return res.Error, p.ID, res.RowsAffected
-		}
-
-		personErr, id, rowsAffected := getCurrentPersonData()
-		/**
-		If the user does not exist, create it alongside the scraped image
-		*/
-		if errors.Is(personErr, gorm.ErrRecordNotFound) {
-			person := createPerson()
-			if res := tx.Create(&person); res.Error != nil {
-				return fmt.Errorf("failed saving to database item_id: %s; -> %v", personId, res.Error)
-			}
+// Parses the page of the person and adds the person to the writer if the page looks as expected.
+func (s *Scraper) process(writer *storage.Writer, layout *scraper.Layout, personId string, body []byte, seenAt time.Time, images *[]scraper.PendingImage) error {
+	parsed, err := htmlParser.Parse(string(body))
+	if err != nil {
+		return fmt.Errorf("failed getting tokens: %s; -> %w", personId, err)
+	}
 
-			img, extension, err := getImage()
-			if err != nil {
-				// the image could not be downloaded but that is not a reason to throw away the transaction
-				// in the next iteration, since this program is in cron, should pick it up. Otherwise, a good
-				// logging system should be created for this use case
-				return nil
-			}
+	tokens, image, err := getTokens(parsed)
+	if err != nil {
+		return fmt.Errorf("failed getting tokens: %s; -> %w", personId, err)
+	}
 
-			dbImage := NewDbImage(person.ID, extension, img)
-			if res := tx.Create(&dbImage); res.Error != nil {
-				return res.Error
-			}
-		} else if personErr != nil {
-			return fmt.Errorf("an error occurred while trying to query the record: %s; -> %w", personId, personErr)
-		}
+	if err := layout.CheckPage(parsed, tokens); err != nil {
+		return fmt.Errorf("%s: %w", personURL(personId), err)
+	}
 
-		/**
-		If the person exists, update it alongside the image
-		*/
-		if rowsAffected == 1 && id != 0 {
-			if res := updateRecord(id, tokens, personId, uniqueIdentifier, tx); res.Error != nil {
-				return fmt.Errorf("failed updating database with item_id: %s; -> %v", personId, res.Error)
-			}
+	return s.save(writer, tokens, personId, personURL(personId), createUniqueIdentifier(tokens), image, seenAt, images)
+}
 
-			img, extension, err := getImage()
-			if err != nil {
-				// the image could not be downloaded but that is not a reason to throw away the transaction
-				// in the next iteration, since this program is in cron, should pick it up. Otherwise, a good
-				// logging system should be created for this use case
-				return nil
-			}
+/*
+*
+Adds the scrapped person to the writer, everything is fetched before so the writes are short. The image of
+the person is downloaded after the run saved every person, it needs the id of the saved row.
+*/
+func (s *Scraper) save(writer *storage.Writer, tokens []string, personId, personURL, uniqueIdentifier, imageSrc string, seenAt time.Time, images *[]scraper.PendingImage) error {
+	b, _ := json.Marshal(tokens)
+	person := NewRawData(b, personId, uniqueIdentifier, personURL, seenAt)
 
-			var dbImg DbImage
-			res := tx.Where("item_id = ?", id).Select("id").First(&dbImg)
-			if res.Error != nil {
-				return nil
-			}
-			dbImg.ItemID = id
-			dbImg.Extension = extension
-			dbImg.Blob = img
-			if res := tx.Where("id = ?", dbImg.ID).Save(&dbImg); res.Error != nil {
-				return res.Error
-			}
-		}
+	return writer.AddRaw(person.Raw, func(saved storage.Raw, _ storage.Outcome) {
+		*images = append(*images, pendingImage(saved.ID, imageSrc))
+	})
+}
 
-		return nil
-	}); err != nil {
-		return err
+// The image src is a path on nestali.gov.hr.
+func pendingImage(rawID int, src string) scraper.PendingImage {
+	if src == "" {
+		return scraper.PendingImage{RawID: rawID, Reason: scraper.ImageNotOnPage}
 	}
 
-	return nil
+	return scraper.PendingImage{RawID: rawID, URL: fmt.Sprintf("https://nestali.gov.hr%s", src)}
 }
 
-func getList(url string) ([]*html.Node, error) {
-	body, err := htmlParser.GetBody(fmt.Sprintf(url))
+func (s *Scraper) getList(url string) ([]*html.Node, error) {
+	body, err := htmlParser.GetBody(s.Fetcher, url)
 	if err != nil {
 		return nil, err
 	}
@@ -205,24 +198,12 @@ func getList(url string) ([]*html.Node, error) {
 
 /*
 *
-Goes to the actual single page of the missing person and scrapps all the data that it can.
+Scrapps all the data that it can from the single page of the missing person.
 It stores that data in an array. How to represent that data should be done later.
 
 This is where the missing person image is also scrapped (the <img> src attribute).
 */
-func getTokens(personId string) ([]string, string, error) {
-	url := fmt.Sprintf("https://nestali.gov.hr/nestale-osobe-403/403?osoba_id=%s", personId)
-
-	body, err := htmlParser.GetBody(fmt.Sprintf(url))
-	if err != nil {
-		return nil, "", err
-	}
-
-	parsed, err := htmlParser.Parse(string(body))
-	if err != nil {
-		return nil, "", err
-	}
-
+func getTokens(parsed *html.Node) ([]string, string, error) {
 	doc, err := cascadia.Parse(".profile_details_right dl *")
 	if err != nil {
 		return nil, "", err
@@ -240,8 +221,9 @@ func getTokens(personId string) ([]string, string, error) {
 	}
 
 	img := cascadia.Query(parsed, imgParse)
-	if err != nil {
-		return nil, "", err
+	if img == nil {
+		// the person has no image
+		return data, "", nil
 	}
 
 	return data, htmlParser.Attr("src", img.Attr), nil
@@ -1,41 +1,62 @@
 package croatia
 
 import (
-	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"missing-persons-scrapper/pkg/scraper"
 	"missing-persons-scrapper/pkg/storage"
+	"time"
 )
 
 const Croatia_Scrapper_Table = "croatia_scrapped"
 const Croatia_Images_Table = "croatia_images"
 
+var tables = storage.Tables{Raw: Croatia_Scrapper_Table, Images: Croatia_Images_Table}
+
+/*
+*
+The version of the parser saved with every row. Increase it when getTokens changes what it extracts, the saved rows are then
+parsed again from the archived pages with: reparse
+*/
+const ParserVersion = 1
+
+/*
+*
+What every person page of nestali.gov.hr has, a page without it is not saved. The site lists hundreds of
+persons, a run that finds fewer did not find the lists.
+*/
+var expectations = scraper.Expectations{
+	Selectors: []string{".profile_details_right dl"},
+	MinTokens: 4,
+	Labels:    []string{"Ime", "Prezime"},
+	MinListed: 100,
+}
+
 type DbImage struct {
-	ID        int    `gorm:"column:id"`
-	ItemID    int    `gorm:"column:item_id"`
-	Extension string `gorm:"column:extension"`
-	Blob      []byte `gorm:"column:blob"`
+	storage.Image
 }
 
 type RawData struct {
-	ID               int
-	Data             datatypes.JSON `gorm:"type:jsonb"`
-	ItemID           string         `gorm:"column:item_id"`
-	UniqueIdentifier string         `gorm:"column:unique_identifier;type:text"`
+	storage.Raw
 }
 
-func NewRawData(data []byte, itemId, uniqueIdentifier string) RawData {
-	return RawData{
+func NewRawData(data []byte, itemId, uniqueIdentifier, sourceURL string, seenAt time.Time) RawData {
+	return RawData{storage.Raw{
 		Data:             data,
 		ItemID:           itemId,
 		UniqueIdentifier: uniqueIdentifier,
-	}
+		SourceURL:        sourceURL,
+		ParserVersion:    ParserVersion,
+		FirstSeen:        seenAt,
+		LastSeen:         seenAt,
+	}}
 }
 
 func NewDbImage(itemId int, extension string, blob []byte) DbImage {
-	return DbImage{
+	return DbImage{storage.Image{
 		ItemID:    itemId,
 		Blob:      blob,
 		Extension: extension,
-	}
+	}}
 }
 
 func (RawData) TableName() string {
@@ -46,14 +67,7 @@ func (DbImage) TableName() string {
 	return "croatia_images"
 }
 
-func Migrate() error {
-	if err := storage.DB.AutoMigrate(&RawData{}); err != nil {
-		return err
-	}
-
-	if err := storage.DB.AutoMigrate(&DbImage{}); err != nil {
-		return err
-	}
-
-	return nil
+// Returns the store of this country's tables in db.
+func NewStore(db *gorm.DB) storage.Store {
+	return storage.NewStore(db, tables)
 }
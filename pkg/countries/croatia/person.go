@@ -0,0 +1,67 @@
+package croatia
+
+import (
+	"encoding/json"
+	"missing-persons-scrapper/pkg/htmlParser"
+	"missing-persons-scrapper/pkg/normalize"
+	"missing-persons-scrapper/pkg/persons"
+)
+
+// labels of the nestali.gov.hr profile details mapped to RawPerson fields
+var labels = map[string]string{
+	"Ime":                "Name",
+	"Prezime":            "LastName",
+	"Djevojačko prezime": "MaidenName",
+	"Spol":               "Gender",
+	"Datum rođenja":      "DOB",
+	"Mjesto rođenja":     "POB",
+	"Državljanstvo":      "Citizenship",
+	"Prebivalište":       "PrimaryAddress",
+	"Boravište":          "SecondaryAddress",
+	"Država":             "Country",
+	"Visina":             "Height",
+	"Težina":             "Weight",
+	"Boja kose":          "Hair",
+	"Boja očiju":         "EyeColor",
+	"Datum nestanka":     "DOD",
+	"Mjesto nestanka":    "POD",
+	"Opis":               "Description",
+	"Okolnosti nestanka": "Description",
+}
+
+func (r RawData) Person() htmlParser.RawPerson {
+	tokens := make([]string, 0)
+	_ = json.Unmarshal(r.Data, &tokens)
+
+	return htmlParser.NewRawPersonFromTokens(tokens, labels)
+}
+
+// Records returns every raw row of this country for normalization.
+func (s Source) Records() ([]persons.Record, error) {
+	var rows []RawData
+	if res := s.db.Find(&rows); res.Error != nil {
+		return nil, res.Error
+	}
+
+	images, err := s.CurrentImages()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]persons.Record, len(rows))
+	for i, r := range rows {
+		records[i] = persons.Record{
+			RawID:         r.ID,
+			ItemID:        r.ItemID,
+			SourceURL:     r.SourceURL,
+			FirstSeen:     r.FirstSeen,
+			RemovedAt:     r.RemovedAt,
+			ImageHash:     images[r.ID].Hash,
+			ImagePHash:    images[r.ID].PHash,
+			ParserVersion: r.ParserVersion,
+			Person:        normalize.NewPerson(r.Person()),
+		}
+	}
+
+	return records, nil
+}
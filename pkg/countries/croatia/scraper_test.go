@@ -0,0 +1,249 @@
+package croatia
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/jpeg"
+	"io"
+	"log"
+	"missing-persons-scrapper/pkg/httpClient"
+	"missing-persons-scrapper/pkg/imagestore"
+	"missing-persons-scrapper/pkg/migrations"
+	"missing-persons-scrapper/pkg/scraper"
+	"missing-persons-scrapper/pkg/storage"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Serves the pages by url, every other list page is empty and every other page is not found.
+type fakeFetcher map[string]string
+
+func (f fakeFetcher) Fetch(url string) (httpClient.Page, error) {
+	if body, ok := f[url]; ok {
+		return httpClient.Page{URL: url, StatusCode: http.StatusOK, Body: []byte(body)}, nil
+	}
+
+	if strings.Contains(url, "slovo=") {
+		return httpClient.Page{URL: url, StatusCode: http.StatusOK, Body: []byte(`<ul class="nestali-list"></ul>`)}, nil
+	}
+
+	return httpClient.Page{URL: url, StatusCode: http.StatusNotFound}, nil
+}
+
+// Answers like a cached fetcher on a run where no page changed.
+type unchangedFetcher struct {
+	fakeFetcher
+}
+
+func (f unchangedFetcher) Fetch(url string) (httpClient.Page, error) {
+	page, err := f.fakeFetcher.Fetch(url)
+	page.Unchanged = page.StatusCode == http.StatusOK
+	return page, err
+}
+
+const testList = `<ul class="nestali-list">
+<li><a class="osoba-ime" href="/nestale-osobe-403/403?osoba_id=7">Marko Horvat</a></li>
+<li><a class="osoba-ime" href="/nestale-osobe-403/403?osoba_id=8">Ana Kovač</a></li>
+</ul>`
+
+const testProfile = `<div class="menuLeftPhoto"><img src="/images/7.jpg"></div>
+<div class="profile_details_right"><dl>
+<dt>Ime</dt><dd>Marko</dd>
+<dt>Prezime</dt><dd>Horvat</dd>
+<dt>Datum nestanka</dt><dd>01.02.2024.</dd>
+</dl></div>`
+
+func testJPEG(t *testing.T) []byte {
+	buff := &bytes.Buffer{}
+	assert.Nil(t, jpeg.Encode(buff, image.NewGray(image.Rect(0, 0, 2, 2)), nil))
+
+	return buff.Bytes()
+}
+
+// the test site lists only a few persons
+func newScraper(deps scraper.Dependencies) *Scraper {
+	s := NewScraper(deps)
+	s.Expectations.MinListed = 0
+
+	return s
+}
+
+func TestScraper(t *testing.T) {
+	db, err := storage.Open(storage.Config{Driver: storage.DriverSQLite, Path: ":memory:"})
+	assert.Nil(t, err)
+	_, err = migrations.Up(db)
+	assert.Nil(t, err)
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fetcher := fakeFetcher{
+		"https://nestali.gov.hr/nestale-osobe-403/403?slovo=a&page=1": testList,
+		personURL("7"):                        testProfile,
+		"https://nestali.gov.hr/images/7.jpg": string(testJPEG(t)),
+		personURL("8"):                        strings.NewReplacer("7.jpg", "8.jpg", "Marko", "Ana").Replace(testProfile),
+		// an error page instead of the image
+		"https://nestali.gov.hr/images/8.jpg": "<html><body>Greška</body></html>",
+	}
+
+	images, err := imagestore.NewFilesystem(t.TempDir())
+	assert.Nil(t, err)
+
+	deps := scraper.Dependencies{
+		Fetcher: fetcher,
+		Store:   NewStore(db),
+		Images:  images,
+		Clock:   func() time.Time { return now },
+		Logger:  log.New(io.Discard, "", 0),
+	}
+
+	run := newScraper(deps).Run()
+	assert.True(t, run.Complete)
+	assert.Equal(t, 2, run.Seen)
+	assert.Equal(t, 2, run.Created)
+	assert.Equal(t, 0, run.Failed)
+
+	raw, err := deps.Store.FindRaw(1)
+	assert.Nil(t, err)
+	assert.Equal(t, "7", raw.ItemID)
+	assert.Equal(t, personURL("7"), raw.SourceURL)
+	assert.Nil(t, raw.RemovedAt)
+
+	img, extension, err := NewSource(db, images).Image("7")
+	assert.Nil(t, err)
+	assert.Equal(t, "jpg", extension)
+	assert.Equal(t, testJPEG(t), img)
+
+	raw, err = deps.Store.FindRaw(2)
+	assert.Nil(t, err)
+	assert.Contains(t, raw.MissingImageReason, "not an image")
+
+	// nothing changed on the site, the persons are only marked as seen
+	now = now.Add(time.Hour)
+	deps.Fetcher = unchangedFetcher{fetcher}
+	run = newScraper(deps).Run()
+	assert.True(t, run.Complete)
+	assert.Equal(t, 2, run.Unchanged)
+	assert.Equal(t, 0, run.Created+run.Updated)
+
+	raw, err = deps.Store.FindRaw(1)
+	assert.Nil(t, err)
+	assert.True(t, raw.LastSeen.Equal(now))
+	deps.Fetcher = fetcher
+
+	// the saved pages are parsed again, the first person is parsed differently
+	reparsed := fakeFetcher{}
+	for url, body := range fetcher {
+		reparsed[url] = body
+	}
+	reparsed[personURL("7")] = strings.Replace(testProfile, "</dl>", "<dt>Visina</dt><dd>180</dd></dl>", 1)
+	delete(reparsed, "https://nestali.gov.hr/images/7.jpg")
+
+	run = newScraper(deps.Reparse(reparsed)).Run()
+	assert.Equal(t, 1, run.Updated)
+	assert.Equal(t, 1, run.Unchanged)
+	assert.Equal(t, 0, run.Created)
+
+	raw, err = deps.Store.FindRaw(1)
+	assert.Nil(t, err)
+	assert.Contains(t, string(raw.Data), "180")
+	assert.Equal(t, ParserVersion, raw.ParserVersion)
+	assert.True(t, raw.LastSeen.Equal(now))
+	assert.Nil(t, raw.RemovedAt)
+
+	// the image was not downloaded again
+	img, _, err = NewSource(db, images).Image("7")
+	assert.Nil(t, err)
+	assert.Equal(t, testJPEG(t), img)
+
+	// the first person is not on the site anymore
+	fetcher["https://nestali.gov.hr/nestale-osobe-403/403?slovo=a&page=1"] = strings.Replace(testList,
+		`<li><a class="osoba-ime" href="/nestale-osobe-403/403?osoba_id=7">Marko Horvat</a></li>`, "", 1)
+	now = now.Add(24 * time.Hour)
+
+	run = newScraper(deps).Run()
+	assert.True(t, run.Complete)
+	assert.Equal(t, int64(1), run.Removed)
+
+	raw, err = deps.Store.FindRaw(1)
+	assert.Nil(t, err)
+	assert.NotNil(t, raw.RemovedAt)
+}
+
+func TestLayoutChanged(t *testing.T) {
+	db, err := storage.Open(storage.Config{Driver: storage.DriverSQLite, Path: ":memory:"})
+	assert.Nil(t, err)
+	_, err = migrations.Up(db)
+	assert.Nil(t, err)
+
+	images, err := imagestore.NewFilesystem(t.TempDir())
+	assert.Nil(t, err)
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fetcher := fakeFetcher{
+		"https://nestali.gov.hr/nestale-osobe-403/403?slovo=a&page=1": testList,
+		personURL("7"): testProfile,
+		personURL("8"): strings.NewReplacer("7.jpg", "8.jpg", "Marko", "Ana").Replace(testProfile),
+	}
+	deps := scraper.Dependencies{
+		Fetcher: fetcher,
+		Store:   NewStore(db),
+		Images:  images,
+		Clock:   func() time.Time { return now },
+		Logger:  log.New(io.Discard, "", 0),
+	}
+
+	run := newScraper(deps).Run()
+	assert.Equal(t, 2, run.Created)
+	assert.False(t, run.LayoutChanged)
+
+	// the details of the persons are in an element the scraper does not know
+	now = now.Add(time.Hour)
+	for _, id := range []string{"7", "8"} {
+		fetcher[personURL(id)] = strings.Replace(fetcher[personURL(id)], "profile_details_right", "profile_details", 1)
+	}
+
+	run = newScraper(deps).Run()
+	assert.False(t, run.Complete)
+	assert.True(t, run.LayoutChanged)
+	assert.Contains(t, run.LayoutAlert, "2 of 2 person pages failed: fewer than 4 tokens (2)")
+	assert.Equal(t, 2, run.Failed)
+	assert.Equal(t, 0, run.Created+run.Updated)
+
+	var rows int64
+	assert.Nil(t, db.Table(Croatia_Scrapper_Table).Count(&rows).Error)
+	assert.Equal(t, int64(2), rows)
+
+	// a run stops once enough pages failed
+	list := &strings.Builder{}
+	list.WriteString(`<ul class="nestali-list">`)
+	for id := 100; id < 130; id++ {
+		fmt.Fprintf(list, `<li><a class="osoba-ime" href="/nestale-osobe-403/403?osoba_id=%d">Osoba</a></li>`, id)
+		fetcher[personURL(strconv.Itoa(id))] = fetcher[personURL("8")]
+	}
+	list.WriteString(`</ul>`)
+	fetcher["https://nestali.gov.hr/nestale-osobe-403/403?slovo=b&page=1"] = list.String()
+
+	run = newScraper(deps).Run()
+	assert.True(t, run.LayoutChanged)
+	assert.Equal(t, scraper.MinCheckedPages, run.Seen)
+
+	// the lists are in an element the scraper does not know, nobody is removed
+	for url, body := range fetcher {
+		fetcher[url] = strings.ReplaceAll(body, "nestali-list", "missing-list")
+	}
+	now = now.Add(24 * time.Hour)
+
+	run = NewScraper(deps).Run()
+	assert.False(t, run.Complete)
+	assert.True(t, run.LayoutChanged)
+	assert.Equal(t, "the list pages linked to no persons", run.LayoutAlert)
+	assert.Equal(t, int64(0), run.Removed)
+
+	raw, err := deps.Store.FindRaw(1)
+	assert.Nil(t, err)
+	assert.Nil(t, raw.RemovedAt)
+}
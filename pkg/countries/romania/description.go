@@ -0,0 +1,45 @@
+package romania
+
+import (
+	_ "embed"
+	"fmt"
+	"missing-persons-scrapper/pkg/extract"
+	"os"
+	"sync"
+)
+
+//go:embed description_rules.yaml
+var defaultDescriptionRules []byte
+
+var (
+	descriptionRules     extract.Rules
+	descriptionRulesErr  error
+	descriptionRulesOnce sync.Once
+)
+
+/*
+*
+Rules for extracting physical details from the description. ROMANIA_DESCRIPTION_RULES can point to another rules
+file, otherwise the rules that come with the program are used. The rules are loaded once, an invalid rules
+file is an error every time.
+*/
+func getDescriptionRules() (extract.Rules, error) {
+	descriptionRulesOnce.Do(func() {
+		rules, err := extract.LoadRules(defaultDescriptionRules)
+		if err != nil {
+			descriptionRulesErr = err
+			return
+		}
+
+		if path := os.Getenv("ROMANIA_DESCRIPTION_RULES"); path != "" {
+			if rules, err = extract.LoadRulesFile(path); err != nil {
+				descriptionRulesErr = fmt.Errorf("ROMANIA_DESCRIPTION_RULES: %w", err)
+				return
+			}
+		}
+
+		descriptionRules = rules
+	})
+
+	return descriptionRules, descriptionRulesErr
+}
@@ -0,0 +1,26 @@
+package romania
+
+import (
+	"gorm.io/gorm"
+	"missing-persons-scrapper/pkg/feed"
+	"missing-persons-scrapper/pkg/htmlParser"
+	"missing-persons-scrapper/pkg/imagestore"
+	"missing-persons-scrapper/pkg/storage"
+)
+
+const Country = "ro"
+
+// Source exposes the scrapped persons of this country to the API.
+type Source struct {
+	feed.Source
+	db *gorm.DB
+}
+
+func NewSource(db *gorm.DB, images imagestore.Store) Source {
+	return Source{Source: feed.NewSource(db, tables, images, Country, person), db: db}
+}
+
+// the person of the data of a raw row
+func person(data []byte) htmlParser.RawPerson {
+	return RawData{storage.Raw{Data: data}}.Person()
+}
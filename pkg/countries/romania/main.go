@@ -3,163 +3,158 @@ package romania
 import (
 	"crypto/sha256"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/andybalholm/cascadia"
 	"golang.org/x/net/html"
-	"gorm.io/gorm"
-	"log"
 	"missing-persons-scrapper/pkg/htmlParser"
+	"missing-persons-scrapper/pkg/scraper"
 	"missing-persons-scrapper/pkg/storage"
 	"strconv"
 	"strings"
-	"sync"
+	"time"
 )
 
-func Start() {
-	pages, err := getNumOfPages("https://www.politiaromana.ro/ro/persoane-disparute")
+// Scrapes politiaromana.ro
+type Scraper struct {
+	scraper.Dependencies
+	// what the pages of politiaromana.ro are checked against
+	Expectations scraper.Expectations
+}
+
+func NewScraper(deps scraper.Dependencies) *Scraper {
+	return &Scraper{Dependencies: deps, Expectations: expectations}
+}
+
+func (s *Scraper) Country() string {
+	return Country
+}
+
+func (s *Scraper) Run() storage.Run {
+	run := storage.Run{Country: Country, StartedAt: s.Clock()}
+	finishArchive := s.BeginRun(&run)
+	defer finishArchive()
+
+	images := make([]scraper.PendingImage, 0)
+	writer := s.Writer()
+	layout := scraper.NewLayout(s.Expectations)
+	// only a run that went through every page and person can tell which persons were removed
+	complete := true
+
+	pages, err := s.getNumOfPages("https://www.politiaromana.ro/ro/persoane-disparute")
 	if err != nil {
-		fmt.Println(fmt.Errorf("failed getting pages. Cannot continue: %w", err))
-		return
+		s.Logger.Println(fmt.Errorf("failed getting pages. Cannot continue: %w", err))
+		complete = false
+		run.Failed++
 	}
 
-	wg := &sync.WaitGroup{}
 	for _, p := range pages {
-		wg.Add(1)
+		// the pages do not look as expected any more
+		if layout.Changed() {
+			complete = false
+			break
+		}
 
-		anchors, err := getList(fmt.Sprintf("https://www.politiaromana.ro/ro/persoane-disparute&page=%d", p))
+		anchors, err := s.getList(fmt.Sprintf("https://www.politiaromana.ro/ro/persoane-disparute&page=%d", p))
 		if err != nil {
-			log.Println(fmt.Errorf("failed to get list: page: %d: %w", p, err))
-			return
+			s.Logger.Println(fmt.Errorf("failed to get list: page: %d: %w", p, err))
+			complete = false
+			run.Failed++
+			continue
 		}
+		layout.List(len(anchors))
 
 		for _, a := range anchors {
 			href := htmlParser.Attr("href", a.Attr)
 
 			personId := getPersonIDFromHref(href)
-			personPage, err := getPersonPage(href)
+			profile, err := htmlParser.GetPage(s.Fetcher, href)
 			if err != nil {
-				log.Println(fmt.Errorf("failed to get individual person page: page: %d: %w", p, err))
+				s.Logger.Println(fmt.Errorf("failed to get individual person page: page: %d: %w", p, err))
+				complete = false
+				run.Failed++
 				continue
 			}
 
-			tokens := make([]string, 0)
-			if err := getBasicInfo(personPage, &tokens); err != nil {
-				log.Println(fmt.Errorf("failed to get basic info: page: %d, %w", p, err))
-				continue
+			seenAt := s.Clock()
+			if profile.Unchanged {
+				// the page is the same as on the last run, the person is only marked as seen
+				err = writer.AddSeen(personId, seenAt, func() error {
+					return s.process(writer, layout, personId, href, profile.Body, seenAt, &images)
+				})
+			} else {
+				err = s.process(writer, layout, personId, href, profile.Body, seenAt, &images)
 			}
 
-			if err := getDescription(personPage, &tokens); err != nil {
-				log.Println(fmt.Errorf("failed to get person description: page: %d, %w", p, err))
-				continue
-			}
-
-			if err := getDetails(personPage, &tokens); err != nil {
-				log.Println(fmt.Errorf("failed to get person details: page: %d, %w", p, err))
-				continue
-			}
-
-			img, err := getImage(personPage)
+			// the writer counts the persons of a batch that failed
 			if err != nil {
-				// we don't have to react if the image src is not there, maybe it will be on one
-				// of the next runs of this program
+				s.Logger.Println(fmt.Errorf("page: %d: %w", p, err))
+				complete = false
 			}
 
-			if err := tryDbOperation(tokens, personId, img, createUniqueIdentifier(tokens)); err != nil {
-				log.Fatalln(err)
+			run.Seen++
+			if layout.Changed() {
+				break
 			}
 		}
 
-		fmt.Printf("Finished page %d\n", p)
+		s.Logger.Printf("Finished page %d\n", p)
 	}
 
-	wg.Wait()
+	return s.FinishRun(run, writer, layout, &images, complete)
 }
 
-func tryDbOperation(tokens []string, personId string, imgSrc, uniqueIdentifier string) error {
-	if err := storage.DB.Transaction(func(tx *gorm.DB) error {
-		createPerson := func() RawData {
-			b, _ := json.Marshal(tokens)
-			return NewRawData(b, personId, uniqueIdentifier)
-		}
-
-		getImage := func() ([]byte, string, error) {
-			buff := strings.Split(imgSrc, ".")
-			if len(buff) < 2 {
-				return nil, "", errors.New("cannot extract image extension")
-			}
+// Parses the page of the person and adds the person to the writer if the page looks as expected.
+func (s *Scraper) process(writer *storage.Writer, layout *scraper.Layout, personId, personURL string, body []byte, seenAt time.Time, images *[]scraper.PendingImage) error {
+	personPage, err := htmlParser.Parse(string(body))
+	if err != nil {
+		return fmt.Errorf("failed to parse individual person page: %w", err)
+	}
 
-			body, err := downloadImage(imgSrc)
-			return body, buff[len(buff)-1], err
-		}
+	tokens := make([]string, 0)
+	if err := getBasicInfo(personPage, &tokens); err != nil {
+		return fmt.Errorf("failed to get basic info: %w", err)
+	}
 
-		getCurrentPersonData := func() (err error, id int, rowsAffected int64) {
-			var p RawData
-			res := tx.Where("unique_identifier = ?", uniqueIdentifier).Select("id").First(&p)
+	if err := getDescription(personPage, &tokens); err != nil {
+		return fmt.Errorf("failed to get person description: %w", err)
+	}
 
-			return res.Error, p.ID, res.RowsAffected
-		}
+	if err := getDetails(personPage, &tokens); err != nil {
+		return fmt.Errorf("failed to get person details: %w", err)
+	}
 
-		personErr, id, rowsAffected := getCurrentPersonData()
-		/**
-		If the user does not exist, create it alongside the scraped image
-		*/
-		if errors.Is(personErr, gorm.ErrRecordNotFound) {
-			person := createPerson()
-			if res := tx.Create(&person); res.Error != nil {
-				return fmt.Errorf("failed saving to database item_id: %s; -> %v", personId, res.Error)
-			}
+	if err := layout.CheckPage(personPage, tokens); err != nil {
+		return fmt.Errorf("%s: %w", personURL, err)
+	}
 
-			img, extension, err := getImage()
-			if err != nil {
-				// the image could not be downloaded but that is not a reason to throw away the transaction
-				// in the next iteration, since this program is in cron, should pick it up. Otherwise, a good
-				// logging system should be created for this use case
-				return nil
-			}
+	// we don't have to react if the image src is not there, maybe it will be on one
+	// of the next runs of this program
+	img, _ := getImage(personPage)
 
-			dbImage := NewDbImage(person.ID, extension, img)
-			if res := tx.Create(&dbImage); res.Error != nil {
-				return res.Error
-			}
-		} else if personErr != nil {
-			return fmt.Errorf("an error occurred while trying to query the record: %s; -> %w", personId, personErr)
-		}
+	return s.save(writer, tokens, personId, personURL, createUniqueIdentifier(tokens), img, seenAt, images)
+}
 
-		/**
-		If the person exists, update it alongside the image
-		*/
-		if rowsAffected == 1 && id != 0 {
-			if res := updateRecord(id, tokens, personId, uniqueIdentifier, tx); res.Error != nil {
-				return fmt.Errorf("failed updating database with item_id: %s; -> %v", personId, res.Error)
-			}
+/*
+*
+Adds the scrapped person to the writer, everything is fetched before so the writes are short. The image of
+the person is downloaded after the run saved every person, it needs the id of the saved row.
+*/
+func (s *Scraper) save(writer *storage.Writer, tokens []string, personId, personURL, uniqueIdentifier, imageSrc string, seenAt time.Time, images *[]scraper.PendingImage) error {
+	b, _ := json.Marshal(tokens)
+	person := NewRawData(b, personId, uniqueIdentifier, personURL, seenAt)
 
-			img, extension, err := getImage()
-			if err != nil {
-				// the image could not be downloaded but that is not a reason to throw away the transaction
-				// in the next iteration, since this program is in cron, should pick it up. Otherwise, a good
-				// logging system should be created for this use case
-				return nil
-			}
+	return writer.AddRaw(person.Raw, func(saved storage.Raw, _ storage.Outcome) {
+		*images = append(*images, pendingImage(saved.ID, imageSrc))
+	})
+}
 
-			var dbImg DbImage
-			res := tx.Where("item_id = ?", id).Select("id").First(&dbImg)
-			if res.Error != nil {
-				return nil
-			}
-			dbImg.ItemID = id
-			dbImg.Extension = extension
-			dbImg.Blob = img
-			if res := tx.Where("id = ?", dbImg.ID).Save(&dbImg); res.Error != nil {
-				return res.Error
-			}
-		}
-		return nil
-	}); err != nil {
-		return err
+// The image src is an absolute url.
+func pendingImage(rawID int, src string) scraper.PendingImage {
+	if src == "" {
+		return scraper.PendingImage{RawID: rawID, Reason: scraper.ImageNotOnPage}
 	}
 
-	return nil
+	return scraper.PendingImage{RawID: rawID, URL: src}
 }
 
 func getPersonIDFromHref(href string) string {
@@ -240,8 +235,8 @@ func createUniqueIdentifier(tokens []string) string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
-func getNumOfPages(url string) ([]int64, error) {
-	body, err := htmlParser.GetBody(fmt.Sprintf(url))
+func (s *Scraper) getNumOfPages(url string) ([]int64, error) {
+	body, err := htmlParser.GetBody(s.Fetcher, url)
 	if err != nil {
 		return nil, err
 	}
@@ -258,7 +253,7 @@ func getNumOfPages(url string) ([]int64, error) {
 	for i, f := range final {
 		p, err := strconv.ParseInt(f.FirstChild.Data, 10, 32)
 		if err != nil {
-			fmt.Errorf("Cannot convert page to number: %v", err)
+			s.Logger.Println(fmt.Errorf("cannot convert page to number: %w", err))
 		}
 
 		pages[i] = p
@@ -267,8 +262,8 @@ func getNumOfPages(url string) ([]int64, error) {
 	return pages, nil
 }
 
-func getList(url string) ([]*html.Node, error) {
-	body, err := htmlParser.GetBody(fmt.Sprintf(url))
+func (s *Scraper) getList(url string) ([]*html.Node, error) {
+	body, err := htmlParser.GetBody(s.Fetcher, url)
 	if err != nil {
 		return nil, err
 	}
@@ -283,25 +278,3 @@ func getList(url string) ([]*html.Node, error) {
 
 	return final, nil
 }
-
-func getPersonPage(url string) (*html.Node, error) {
-	body, err := htmlParser.GetBody(url)
-	if err != nil {
-		return nil, err
-	}
-
-	parsed, err := htmlParser.Parse(string(body))
-	if err != nil {
-		return nil, err
-	}
-
-	return parsed, nil
-}
-
-func updateRecord(id int, tokens []string, personId, uniqueIdentifier string, tx *gorm.DB) *gorm.DB {
-	b, _ := json.Marshal(tokens)
-	data := NewRawData(b, personId, uniqueIdentifier)
-	data.ID = id
-
-	return tx.Save(&data)
-}
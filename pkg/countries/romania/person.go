@@ -0,0 +1,89 @@
+package romania
+
+import (
+	"encoding/json"
+	"missing-persons-scrapper/pkg/htmlParser"
+	"missing-persons-scrapper/pkg/normalize"
+	"missing-persons-scrapper/pkg/persons"
+	"strings"
+)
+
+// labels of the politiaromana.ro person details mapped to RawPerson fields
+var labels = map[string]string{
+	"Nume":              "LastName",
+	"Prenume":           "Name",
+	"Sex":               "Gender",
+	"Data nașterii":     "DOB",
+	"Data naşterii":     "DOB",
+	"Locul nașterii":    "POB",
+	"Locul naşterii":    "POB",
+	"Cetățenie":         "Citizenship",
+	"Cetăţenie":         "Citizenship",
+	"Domiciliu":         "PrimaryAddress",
+	"Reședința":         "SecondaryAddress",
+	"Înălțime":          "Height",
+	"Înălţime":          "Height",
+	"Greutate":          "Weight",
+	"Culoarea părului":  "Hair",
+	"Culoarea ochilor":  "EyeColor",
+	"Data dispariției":  "DOD",
+	"Data dispariţiei":  "DOD",
+	"Locul dispariției": "POD",
+	"Locul dispariţiei": "POD",
+}
+
+/*
+*
+The labeled details of the person. Most of the physical details are only in the free text description,
+those are extracted with the description rules.
+*/
+func (r RawData) Person() htmlParser.RawPerson {
+	tokens := make([]string, 0)
+	_ = json.Unmarshal(r.Data, &tokens)
+
+	person := htmlParser.NewRawPersonFromTokens(tokens, labels)
+	if person.Description == "" {
+		person.Description = strings.Join(htmlParser.UnlabeledTokens(tokens, labels), "\n")
+	}
+
+	// Records fails if the rules cannot be loaded
+	if rules, err := getDescriptionRules(); err == nil {
+		rules.Apply(&person, person.Description)
+	}
+
+	return person
+}
+
+// Records returns every raw row of this country for normalization.
+func (s Source) Records() ([]persons.Record, error) {
+	if _, err := getDescriptionRules(); err != nil {
+		return nil, err
+	}
+
+	var rows []RawData
+	if res := s.db.Find(&rows); res.Error != nil {
+		return nil, res.Error
+	}
+
+	images, err := s.CurrentImages()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]persons.Record, len(rows))
+	for i, r := range rows {
+		records[i] = persons.Record{
+			RawID:         r.ID,
+			ItemID:        r.ItemID,
+			SourceURL:     r.SourceURL,
+			FirstSeen:     r.FirstSeen,
+			RemovedAt:     r.RemovedAt,
+			ImageHash:     images[r.ID].Hash,
+			ImagePHash:    images[r.ID].PHash,
+			ParserVersion: r.ParserVersion,
+			Person:        normalize.NewPerson(r.Person()),
+		}
+	}
+
+	return records, nil
+}
@@ -0,0 +1,173 @@
+package romania
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/jpeg"
+	"io"
+	"log"
+	"missing-persons-scrapper/pkg/httpClient"
+	"missing-persons-scrapper/pkg/imagestore"
+	"missing-persons-scrapper/pkg/migrations"
+	"missing-persons-scrapper/pkg/scraper"
+	"missing-persons-scrapper/pkg/storage"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Serves the pages by url, every other page is not found.
+type fakeFetcher map[string]string
+
+func (f fakeFetcher) Fetch(url string) (httpClient.Page, error) {
+	if body, ok := f[url]; ok {
+		return httpClient.Page{URL: url, StatusCode: http.StatusOK, Body: []byte(body)}, nil
+	}
+
+	return httpClient.Page{URL: url, StatusCode: http.StatusNotFound}, nil
+}
+
+const (
+	testPages = "https://www.politiaromana.ro/ro/persoane-disparute"
+	testList  = "https://www.politiaromana.ro/ro/persoane-disparute&page=1"
+	testIon   = "https://www.politiaromana.ro/ro/persoane-disparute/ion-popescu-11"
+	testAna   = "https://www.politiaromana.ro/ro/persoane-disparute/ana-pop-12"
+)
+
+const testProfile = `<div class="pozaDetaliiDisparuti"><img src="https://www.politiaromana.ro/images/11.jpg"></div>
+<div class="descDetaliiDisparuti"><span>Nume</span><span>Popescu</span><span>Prenume</span><span>Ion</span></div>
+<div class="semnalmenteDisparuti"><p>înălțime 1,70 m</p></div>`
+
+func testJPEG(t *testing.T) []byte {
+	buff := &bytes.Buffer{}
+	assert.Nil(t, jpeg.Encode(buff, image.NewGray(image.Rect(0, 0, 2, 2)), nil))
+
+	return buff.Bytes()
+}
+
+func listPage(urls ...string) string {
+	b := &strings.Builder{}
+	b.WriteString(`<div class="contentList">`)
+	for _, u := range urls {
+		b.WriteString(`<div class="boxPoza"><a href="` + u + `"></a></div>`)
+	}
+	b.WriteString(`</div>`)
+
+	return b.String()
+}
+
+// the test site lists only a few persons
+func newScraper(deps scraper.Dependencies) *Scraper {
+	s := NewScraper(deps)
+	s.Expectations.MinListed = 0
+
+	return s
+}
+
+func TestScraper(t *testing.T) {
+	db, err := storage.Open(storage.Config{Driver: storage.DriverSQLite, Path: ":memory:"})
+	assert.Nil(t, err)
+	_, err = migrations.Up(db)
+	assert.Nil(t, err)
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fetcher := fakeFetcher{
+		testPages: `<select id="num_page"><option>1</option></select>`,
+		testList:  listPage(testIon, testAna),
+		testIon:   testProfile,
+		// the second person has no image
+		testAna: strings.NewReplacer(`<div class="pozaDetaliiDisparuti"><img src="https://www.politiaromana.ro/images/11.jpg"></div>`, "",
+			"Popescu", "Pop", "Ion", "Ana").Replace(testProfile),
+		"https://www.politiaromana.ro/images/11.jpg": string(testJPEG(t)),
+	}
+
+	images, err := imagestore.NewFilesystem(t.TempDir())
+	assert.Nil(t, err)
+
+	deps := scraper.Dependencies{
+		Fetcher: fetcher,
+		Store:   NewStore(db),
+		Images:  images,
+		Clock:   func() time.Time { return now },
+		Logger:  log.New(io.Discard, "", 0),
+	}
+
+	run := newScraper(deps).Run()
+	assert.True(t, run.Complete)
+	assert.Equal(t, 2, run.Seen)
+	assert.Equal(t, 2, run.Created)
+	assert.Equal(t, 0, run.Failed)
+
+	source := NewSource(db, images)
+	records, err := source.Records()
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "11", records[0].ItemID)
+	assert.Equal(t, testIon, records[0].SourceURL)
+	assert.Equal(t, "Ion", records[0].Person.Name)
+	assert.Equal(t, "Popescu", records[0].Person.LastName)
+
+	img, extension, err := source.Image("11")
+	assert.Nil(t, err)
+	assert.Equal(t, "jpg", extension)
+	assert.Equal(t, testJPEG(t), img)
+
+	raw, err := deps.Store.FindRaw(records[1].RawID)
+	assert.Nil(t, err)
+	assert.Equal(t, scraper.ImageNotOnPage, raw.MissingImageReason)
+
+	// the first person is not on the site anymore
+	fetcher[testList] = listPage(testAna)
+	now = now.Add(24 * time.Hour)
+
+	run = newScraper(deps).Run()
+	assert.True(t, run.Complete)
+	assert.Equal(t, 1, run.Unchanged)
+	assert.Equal(t, int64(1), run.Removed)
+
+	raw, err = deps.Store.FindRaw(records[0].RawID)
+	assert.Nil(t, err)
+	assert.NotNil(t, raw.RemovedAt)
+}
+
+func TestLayoutChanged(t *testing.T) {
+	db, err := storage.Open(storage.Config{Driver: storage.DriverSQLite, Path: ":memory:"})
+	assert.Nil(t, err)
+	_, err = migrations.Up(db)
+	assert.Nil(t, err)
+
+	images, err := imagestore.NewFilesystem(t.TempDir())
+	assert.Nil(t, err)
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fetcher := fakeFetcher{
+		testPages: `<select id="num_page"><option>1</option></select>`,
+		testList:  listPage(testIon),
+		// the details of the person are in an element the scraper does not know
+		testIon: strings.ReplaceAll(testProfile, "descDetaliiDisparuti", "detaliiDisparut"),
+	}
+	deps := scraper.Dependencies{
+		Fetcher: fetcher,
+		Store:   NewStore(db),
+		Images:  images,
+		Clock:   func() time.Time { return now },
+		Logger:  log.New(io.Discard, "", 0),
+	}
+
+	run := newScraper(deps).Run()
+	assert.False(t, run.Complete)
+	assert.True(t, run.LayoutChanged)
+	assert.Contains(t, run.LayoutAlert, "1 of 1 person pages failed")
+	assert.Equal(t, 0, run.Created)
+
+	// the pages have no list of pages, nobody is listed
+	fetcher[testPages] = `<select id="pages"></select>`
+
+	run = newScraper(deps).Run()
+	assert.False(t, run.Complete)
+	assert.True(t, run.LayoutChanged)
+	assert.Equal(t, "the list pages linked to no persons", run.LayoutAlert)
+	assert.Equal(t, int64(0), run.Removed)
+}
@@ -0,0 +1,232 @@
+package derivatives
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"missing-persons-scrapper/pkg/imagefile"
+	"missing-persons-scrapper/pkg/imagestore"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultSizes = "thumb:96,small:320,large:800"
+
+var ErrUnknownSize = errors.New("unknown image size")
+
+// A named size, derivatives fit in Max x Max pixels.
+type Size struct {
+	Name string
+	Max  int
+}
+
+/*
+*
+Parses sizes written as name:pixels separated by commas, e.g. thumb:96,small:320,large:800.
+*/
+func ParseSizes(value string) ([]Size, error) {
+	sizes := make([]Size, 0)
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, pixels, ok := strings.Cut(part, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid image size %q, expected name:pixels", part)
+		}
+
+		n, err := strconv.Atoi(pixels)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid pixels of image size %q", part)
+		}
+
+		if seen[name] {
+			return nil, fmt.Errorf("image size %s is defined twice", name)
+		}
+		seen[name] = true
+
+		sizes = append(sizes, Size{Name: name, Max: n})
+	}
+
+	return sizes, nil
+}
+
+// IMAGE_SIZES configures the sizes, DefaultSizes when it is not set.
+func SizesFromEnv() ([]Size, error) {
+	value := os.Getenv("IMAGE_SIZES")
+	if value == "" {
+		value = DefaultSizes
+	}
+
+	return ParseSizes(value)
+}
+
+/*
+*
+Generates the derivatives of the images in the image store and keeps their files in the same store.
+*/
+type Generator struct {
+	db     *gorm.DB
+	images imagestore.Store
+	sizes  []Size
+}
+
+func NewGenerator(db *gorm.DB, images imagestore.Store, sizes []Size) *Generator {
+	return &Generator{db: db, images: images, sizes: sizes}
+}
+
+/*
+*
+Generates every size of the source images that does not have one yet or has one generated for different
+pixels. Images that cannot be read are logged and skipped. Returns the number of generated derivatives.
+*/
+func (g *Generator) Generate(sourceHashes []string) (int, error) {
+	generated := 0
+
+	for _, hashes := range chunks(unique(sourceHashes), 500) {
+		var existing []Derivative
+		if res := g.db.Where("source_hash IN ?", hashes).Find(&existing); res.Error != nil {
+			return generated, res.Error
+		}
+
+		current := make(map[string]bool, len(existing))
+		for _, d := range existing {
+			current[d.SourceHash+"/"+d.Size+"/"+strconv.Itoa(d.MaxSize)] = true
+		}
+
+		for _, hash := range hashes {
+			var source []byte
+
+			for _, size := range g.sizes {
+				if current[hash+"/"+size.Name+"/"+strconv.Itoa(size.Max)] {
+					continue
+				}
+
+				// the source is read once for all of its sizes
+				if source == nil {
+					blob, err := g.images.Get(hash)
+					if err != nil {
+						log.Println(fmt.Errorf("failed reading image %s: %w", hash, err))
+						break
+					}
+					source = blob
+				}
+
+				if _, err := g.generate(hash, source, size); errors.Is(err, imagefile.ErrNotImage) {
+					log.Println(fmt.Errorf("failed resizing image %s: %w", hash, err))
+					break
+				} else if err != nil {
+					return generated, err
+				}
+
+				generated++
+			}
+		}
+	}
+
+	return generated, nil
+}
+
+/*
+*
+Returns the derivative of the source image in the named size and its mime type. A derivative that is missing
+or outdated is generated first.
+*/
+func (g *Generator) Get(sourceHash, sizeName string) ([]byte, string, error) {
+	size, ok := g.size(sizeName)
+	if !ok {
+		return nil, "", fmt.Errorf("%w %s", ErrUnknownSize, sizeName)
+	}
+
+	var d Derivative
+	res := g.db.Where("source_hash = ? AND size = ? AND max_size = ?", sourceHash, size.Name, size.Max).Limit(1).Find(&d)
+	if res.Error != nil {
+		return nil, "", res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		source, err := g.images.Get(sourceHash)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if d, err = g.generate(sourceHash, source, size); err != nil {
+			return nil, "", err
+		}
+	}
+
+	blob, err := g.images.Get(d.Hash)
+	return blob, d.MimeType, err
+}
+
+func (g *Generator) size(name string) (Size, bool) {
+	for _, s := range g.sizes {
+		if s.Name == name {
+			return s, true
+		}
+	}
+
+	return Size{}, false
+}
+
+func (g *Generator) generate(sourceHash string, source []byte, size Size) (Derivative, error) {
+	file, err := imagefile.Thumbnail(source, size.Max)
+	if err != nil {
+		return Derivative{}, err
+	}
+
+	hash, err := g.images.Put(file.Data, file.Format.MimeType)
+	if err != nil {
+		return Derivative{}, err
+	}
+
+	d := Derivative{
+		SourceHash: sourceHash,
+		Size:       size.Name,
+		MaxSize:    size.Max,
+		Hash:       hash,
+		MimeType:   file.Format.MimeType,
+		Width:      file.Width,
+		Height:     file.Height,
+		CreatedAt:  time.Now(),
+	}
+
+	res := g.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "source_hash"}, {Name: "size"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_size", "hash", "mime_type", "width", "height", "created_at"}),
+	}).Create(&d)
+
+	return d, res.Error
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v == "" || seen[v] {
+			continue
+		}
+
+		seen[v] = true
+		result = append(result, v)
+	}
+
+	return result
+}
+
+func chunks(values []string, size int) [][]string {
+	result := make([][]string, 0)
+	for start := 0; start < len(values); start += size {
+		result = append(result, values[start:min(start+size, len(values))])
+	}
+
+	return result
+}
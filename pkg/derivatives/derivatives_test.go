@@ -0,0 +1,91 @@
+package derivatives
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"missing-persons-scrapper/pkg/imagestore"
+	"missing-persons-scrapper/pkg/migrations"
+	"missing-persons-scrapper/pkg/storage"
+	"testing"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	buff := &bytes.Buffer{}
+	assert.Nil(t, png.Encode(buff, image.NewGray(image.Rect(0, 0, w, h))))
+
+	return buff.Bytes()
+}
+
+func TestParseSizes(t *testing.T) {
+	sizes, err := ParseSizes(DefaultSizes)
+	assert.Nil(t, err)
+	assert.Equal(t, []Size{{"thumb", 96}, {"small", 320}, {"large", 800}}, sizes)
+
+	_, err = ParseSizes("thumb:96,thumb:120")
+	assert.NotNil(t, err)
+
+	_, err = ParseSizes("thumb")
+	assert.NotNil(t, err)
+
+	_, err = ParseSizes("thumb:0")
+	assert.NotNil(t, err)
+}
+
+func TestGenerate(t *testing.T) {
+	db, err := storage.Open(storage.Config{Driver: storage.DriverSQLite, Path: ":memory:"})
+	assert.Nil(t, err)
+	_, err = migrations.Up(db)
+	assert.Nil(t, err)
+
+	images, err := imagestore.NewFilesystem(t.TempDir())
+	assert.Nil(t, err)
+
+	source, err := images.Put(testPNG(t, 400, 200), "image/png")
+	assert.Nil(t, err)
+
+	g := NewGenerator(db, images, []Size{{"thumb", 96}, {"small", 320}})
+	generated, err := g.Generate([]string{source, source})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, generated)
+
+	// nothing changed
+	generated, err = g.Generate([]string{source})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, generated)
+
+	blob, mimeType, err := g.Get(source, "thumb")
+	assert.Nil(t, err)
+	assert.Equal(t, "image/jpeg", mimeType)
+	decoded, err := jpeg.Decode(bytes.NewReader(blob))
+	assert.Nil(t, err)
+	assert.Equal(t, image.Rect(0, 0, 96, 48), decoded.Bounds())
+
+	_, _, err = g.Get(source, "huge")
+	assert.ErrorIs(t, err, ErrUnknownSize)
+
+	// a size that changed is generated again, the other one is kept
+	g = NewGenerator(db, images, []Size{{"thumb", 64}, {"small", 320}})
+	generated, err = g.Generate([]string{source})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, generated)
+
+	var count int64
+	assert.Nil(t, db.Model(&Derivative{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+
+	// a new version of the image gets its own derivatives, generated on the first request
+	changed, err := images.Put(testPNG(t, 100, 300), "image/png")
+	assert.Nil(t, err)
+
+	blob, _, err = g.Get(changed, "thumb")
+	assert.Nil(t, err)
+	decoded, err = jpeg.Decode(bytes.NewReader(blob))
+	assert.Nil(t, err)
+	assert.Equal(t, image.Rect(0, 0, 21, 64), decoded.Bounds())
+
+	_, _, err = g.Get(imagestore.Key([]byte("missing")), "thumb")
+	assert.ErrorIs(t, err, imagestore.ErrNotFound)
+}
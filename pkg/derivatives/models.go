@@ -0,0 +1,28 @@
+package derivatives
+
+import "time"
+
+const Derivatives_Table = "image_derivatives"
+
+/*
+*
+A resized copy of an image. Derivatives belong to the content hash of the source image, so a person whose
+image changed gets new derivatives and the old ones stay with the old version. The file is kept in the image
+store under Hash.
+*/
+type Derivative struct {
+	ID         int    `gorm:"column:id"`
+	SourceHash string `gorm:"column:source_hash"`
+	Size       string `gorm:"column:size"`
+	// the size the derivative was generated for, it is generated again when the size changes
+	MaxSize   int       `gorm:"column:max_size"`
+	Hash      string    `gorm:"column:hash"`
+	MimeType  string    `gorm:"column:mime_type"`
+	Width     int       `gorm:"column:width"`
+	Height    int       `gorm:"column:height"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (Derivative) TableName() string {
+	return Derivatives_Table
+}
@@ -0,0 +1,335 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"missing-persons-scrapper/pkg/htmlParser"
+	"missing-persons-scrapper/pkg/scraper"
+	"missing-persons-scrapper/pkg/storage"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// How the list pages of a site follow each other.
+const (
+	// every letter of Letters has pages 1, 2, ... until a page lists no persons ({letter} and {page})
+	PaginationLetters = "letters"
+	// the page numbers are the options of a select on PagesURL ({page})
+	PaginationSelect = "select"
+	// every list page links to the next one
+	PaginationNext = "next"
+	// a single list page
+	PaginationSingle = "single"
+)
+
+// pages of a letter or of a site that links to the next page, so a site that never ends does not run forever
+const DefaultMaxPages = 500
+
+var validName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// the country codes of the API and the feeds
+var validCountry = regexp.MustCompile(`^[a-z]+$`)
+
+/*
+*
+A country scraped by the engine, read from a YAML file. The site has list pages that link to a page for every
+person, the person page has the details of the person as label/value pairs and an image:
+
+	country: si
+	name: slovenia
+	parser_version: 1
+	list:
+	  url: https://www.policija.si/pogresane-osebe?page={page}
+	  pagination:
+	    type: next
+	    next: .pagination a.next
+	  items: .missing-list a.person
+	  id: 'id=(\d+)'
+	person:
+	  tokens: [".details dt", ".details dd"]
+	  fields:
+	    Description: .description
+	  image: .photo img
+	labels:
+	  Ime: Name
+	  Priimek: LastName
+	expect:
+	  selectors: [.details]
+	  min_tokens: 4
+	  labels: [Ime, Priimek]
+	  min_listed: 20
+
+Tokens are the texts of the elements matched by the token selectors in the order of the page, a token that is
+a label takes the token after it as its value (see htmlParser.NewRawPersonFromTokens). Fields take the whole
+text of the element as the value of a RawPerson field. A person page that does not meet the expectations is
+not saved and too many of them stop the run, see scraper.Layout.
+*/
+type Definition struct {
+	// the country code of the API and the feeds
+	Country string `yaml:"country"`
+	// the tables of the country are <name>_scrapped and <name>_images, created by migrate up
+	Name string `yaml:"name"`
+	// saved with every row, increase it when the selectors change what is extracted
+	ParserVersion int        `yaml:"parser_version"`
+	List          List       `yaml:"list"`
+	Person        PersonPage `yaml:"person"`
+	// labels of the person page mapped to RawPerson fields
+	Labels map[string]string `yaml:"labels"`
+	// what every page of the site has, so a changed layout is not saved as empty persons, min_listed is required
+	Expect scraper.Expectations `yaml:"expect"`
+
+	id *regexp.Regexp
+}
+
+type List struct {
+	// URL of a list page, {letter} and {page} are replaced as the pagination goes
+	URL        string     `yaml:"url"`
+	Pagination Pagination `yaml:"pagination"`
+	// the links to the person pages
+	Items string `yaml:"items"`
+	// the attribute of an item with the URL of the person page, href if empty
+	LinkAttr string `yaml:"link_attr"`
+	// regular expression matched against the URL of the person page, the first group is the website id
+	ID string `yaml:"id"`
+}
+
+type Pagination struct {
+	// one of the Pagination constants, single if empty
+	Type    string   `yaml:"type"`
+	Letters []string `yaml:"letters"`
+	// the number of the first page, 1 if empty
+	FirstPage int `yaml:"first_page"`
+	// select: the page with the select of the pages and the selector of its options
+	PagesURL string `yaml:"pages_url"`
+	Options  string `yaml:"options"`
+	// next: the link to the next list page
+	Next string `yaml:"next"`
+	// DefaultMaxPages if empty
+	MaxPages int `yaml:"max_pages"`
+}
+
+type PersonPage struct {
+	Tokens []string          `yaml:"tokens"`
+	Fields map[string]string `yaml:"fields"`
+	// the image of the person and the attribute with its URL, src if empty
+	Image     string `yaml:"image"`
+	ImageAttr string `yaml:"image_attr"`
+}
+
+// Loads a definition, a key that is not known is an error so a misspelled key is not ignored.
+func LoadDefinition(data []byte) (*Definition, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var def Definition
+	if err := decoder.Decode(&def); err != nil {
+		return nil, err
+	}
+
+	if def.Pagination().Type == "" {
+		def.List.Pagination.Type = PaginationSingle
+	}
+	if def.List.Pagination.FirstPage == 0 {
+		def.List.Pagination.FirstPage = 1
+	}
+	if def.List.Pagination.MaxPages == 0 {
+		def.List.Pagination.MaxPages = DefaultMaxPages
+	}
+	if def.List.LinkAttr == "" {
+		def.List.LinkAttr = "href"
+	}
+	if def.Person.ImageAttr == "" {
+		def.Person.ImageAttr = "src"
+	}
+
+	if err := def.validate(); err != nil {
+		return nil, fmt.Errorf("country %s: %w", def.Country, err)
+	}
+
+	return &def, nil
+}
+
+func LoadDefinitionFile(path string) (*Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	def, err := LoadDefinition(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return def, nil
+}
+
+/*
+*
+Loads every .yaml and .yml file in dir, sorted by file name. A directory that does not exist has no
+definitions. Two definitions of the same country or with the same tables are an error.
+*/
+func LoadDefinitions(dir string) ([]*Definition, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if ext := filepath.Ext(e.Name()); !e.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	defs := make([]*Definition, 0, len(names))
+	countries := make(map[string]bool)
+	tables := make(map[string]bool)
+	for _, name := range names {
+		def, err := LoadDefinitionFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		if countries[def.Country] || tables[def.Name] {
+			return nil, fmt.Errorf("%s: country %s (%s) is defined twice", name, def.Country, def.Name)
+		}
+		countries[def.Country] = true
+		tables[def.Name] = true
+
+		defs = append(defs, def)
+	}
+
+	return defs, nil
+}
+
+/*
+*
+Loads the definitions in COUNTRIES_DIR, ./countries by default.
+*/
+func FromEnv() ([]*Definition, error) {
+	dir := os.Getenv("COUNTRIES_DIR")
+	if dir == "" {
+		dir = "countries"
+	}
+
+	return LoadDefinitions(dir)
+}
+
+func (d *Definition) Pagination() Pagination {
+	return d.List.Pagination
+}
+
+func (d *Definition) Tables() storage.Tables {
+	return storage.Tables{Raw: d.Name + "_scrapped", Images: d.Name + "_images"}
+}
+
+func (d *Definition) validate() error {
+	if !validCountry.MatchString(d.Country) {
+		return fmt.Errorf("invalid country code %q, lower case letters only", d.Country)
+	}
+
+	// the name is part of the table names
+	if !validName.MatchString(d.Name) {
+		return fmt.Errorf("invalid name %q, lower case letters, digits and _ only", d.Name)
+	}
+
+	if d.List.URL == "" {
+		return errors.New("list.url is required")
+	}
+
+	id, err := regexp.Compile(d.List.ID)
+	if err != nil {
+		return fmt.Errorf("invalid list.id: %w", err)
+	}
+	if d.List.ID == "" || id.NumSubexp() < 1 {
+		return errors.New("list.id needs a group that matches the website id, for example 'id=(\\d+)'")
+	}
+	d.id = id
+
+	selectors := map[string]string{"list.items": d.List.Items}
+	for i, t := range d.Person.Tokens {
+		selectors[fmt.Sprintf("person.tokens[%d]", i)] = t
+	}
+	for field, f := range d.Person.Fields {
+		selectors["person.fields."+field] = f
+	}
+	for i, e := range d.Expect.Selectors {
+		selectors[fmt.Sprintf("expect.selectors[%d]", i)] = e
+	}
+
+	p := d.Pagination()
+	switch p.Type {
+	case PaginationLetters:
+		if len(p.Letters) == 0 || !strings.Contains(d.List.URL, "{letter}") || !strings.Contains(d.List.URL, "{page}") {
+			return errors.New("letters pagination needs pagination.letters and a list.url with {letter} and {page}")
+		}
+	case PaginationSelect:
+		if p.PagesURL == "" || !strings.Contains(d.List.URL, "{page}") {
+			return errors.New("select pagination needs pagination.pages_url and a list.url with {page}")
+		}
+		selectors["pagination.options"] = p.Options
+	case PaginationNext:
+		selectors["pagination.next"] = p.Next
+	case PaginationSingle:
+	default:
+		return fmt.Errorf("unknown pagination %q", p.Type)
+	}
+
+	for name, sel := range selectors {
+		if sel == "" {
+			return fmt.Errorf("%s is required", name)
+		}
+
+		if _, err := cascadia.Parse(sel); err != nil {
+			return fmt.Errorf("invalid selector %s: %w", name, err)
+		}
+	}
+
+	// the image is optional
+	if d.Person.Image != "" {
+		if _, err := cascadia.Parse(d.Person.Image); err != nil {
+			return fmt.Errorf("invalid selector person.image: %w", err)
+		}
+	}
+
+	// without it a run whose list selectors stopped matching would only be caught if it listed no one
+	if d.Expect.MinListed < 1 {
+		return errors.New("expect.min_listed is required, the least number of persons a complete run lists")
+	}
+
+	if d.Expect.MinTokens < 0 || d.Expect.MaxFailures < 0 || d.Expect.MaxFailures >= 1 {
+		return errors.New("expect: min_tokens cannot be negative, max_failures is a share below 1")
+	}
+
+	for field := range d.Person.Fields {
+		if !isField(field) {
+			return fmt.Errorf("person.fields: unknown field %s", field)
+		}
+	}
+
+	for label, field := range d.Labels {
+		if !isField(field) {
+			return fmt.Errorf("labels: unknown field %s of %s", field, label)
+		}
+	}
+
+	return nil
+}
+
+// RawPerson ignores unknown fields, a field is known if it keeps its value
+func isField(field string) bool {
+	p := htmlParser.NewRawPerson()
+	p.Set(field, "x")
+
+	return p.Get(field) == "x"
+}
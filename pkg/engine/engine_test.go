@@ -0,0 +1,224 @@
+package engine
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"image"
+	"image/jpeg"
+	"io"
+	"log"
+	"missing-persons-scrapper/pkg/httpClient"
+	"missing-persons-scrapper/pkg/imagestore"
+	"missing-persons-scrapper/pkg/migrations"
+	"missing-persons-scrapper/pkg/scraper"
+	"missing-persons-scrapper/pkg/storage"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Serves the pages by url, every other page is not found.
+type fakeFetcher map[string]string
+
+func (f fakeFetcher) Fetch(url string) (httpClient.Page, error) {
+	if body, ok := f[url]; ok {
+		return httpClient.Page{URL: url, StatusCode: http.StatusOK, Body: []byte(body)}, nil
+	}
+
+	return httpClient.Page{URL: url, StatusCode: http.StatusNotFound}, nil
+}
+
+func testDB(t *testing.T, def *Definition) *gorm.DB {
+	db, err := storage.Open(storage.Config{Driver: storage.DriverSQLite, Path: ":memory:"})
+	assert.Nil(t, err)
+	_, err = migrations.Up(db)
+	assert.Nil(t, err)
+	_, err = migrations.UpTables(db, def.Tables())
+	assert.Nil(t, err)
+
+	return db
+}
+
+func testDependencies(t *testing.T, db *gorm.DB, def *Definition, fetcher httpClient.Fetcher) scraper.Dependencies {
+	images, err := imagestore.NewFilesystem(t.TempDir())
+	assert.Nil(t, err)
+
+	return scraper.Dependencies{
+		Fetcher: fetcher,
+		Store:   NewStore(db, def),
+		Images:  images,
+		Clock:   func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) },
+		Logger:  log.New(io.Discard, "", 0),
+	}
+}
+
+func testJPEG(t *testing.T) []byte {
+	buff := &bytes.Buffer{}
+	assert.Nil(t, jpeg.Encode(buff, image.NewGray(image.Rect(0, 0, 2, 2)), nil))
+
+	return buff.Bytes()
+}
+
+const croatiaProfile = `<div class="menuLeftPhoto"><img src="/images/7.jpg"></div>
+<div class="profile_details_right"><dl>
+<dt>Ime</dt><dd>Marko</dd>
+<dt>Prezime</dt><dd>Horvat</dd>
+<dt>Datum nestanka</dt><dd>01.02.2024.</dd>
+</dl></div>`
+
+func TestLoadDefinitions(t *testing.T) {
+	defs, err := LoadDefinitions("testdata")
+	assert.Nil(t, err)
+	assert.Len(t, defs, 2)
+	assert.Equal(t, "hr", defs[0].Country)
+	assert.Equal(t, storage.Tables{Raw: "croatia_engine_scrapped", Images: "croatia_engine_images"}, defs[0].Tables())
+	assert.Equal(t, 1, defs[0].Pagination().FirstPage)
+	assert.Equal(t, PaginationSelect, defs[1].Pagination().Type)
+
+	defs, err = LoadDefinitions("does-not-exist")
+	assert.Nil(t, err)
+	assert.Empty(t, defs)
+}
+
+func TestInvalidDefinitions(t *testing.T) {
+	valid := "country: si\nname: slovenia\nlist: {url: 'https://policija.si/list', items: a.person, id: 'id=(\\d+)'}\nexpect: {min_listed: 20}\n"
+	_, err := LoadDefinition([]byte(valid))
+	assert.Nil(t, err)
+
+	for _, def := range []string{
+		strings.Replace(valid, "name: slovenia", "name: slovenia; DROP TABLE persons", 1),
+		strings.Replace(valid, "country: si", "country: si_1", 1),
+		strings.Replace(valid, "id=(\\d+)", "id=\\d+", 1),
+		strings.Replace(valid, "a.person", "a[", 1),
+		strings.Replace(valid, "items:", "pagination: {type: pages}, items:", 1),
+		valid + "image: .photo img\n",
+		valid + "labels: {Ime: FirstName}\n",
+		strings.Replace(valid, "min_listed: 20", "min_listed: 20, selectors: ['.details[']", 1),
+		strings.Replace(valid, "min_listed: 20", "min_listed: 20, max_failures: 1.5", 1),
+		strings.Replace(valid, "min_listed: 20", "min_listed: 0", 1),
+	} {
+		_, err := LoadDefinition([]byte(def))
+		assert.NotNil(t, err, def)
+	}
+}
+
+func TestLetters(t *testing.T) {
+	def, err := LoadDefinitionFile("testdata/croatia.yaml")
+	assert.Nil(t, err)
+	// the fake site lists two persons
+	def.Expect.MinListed = 2
+	db := testDB(t, def)
+
+	list := `<ul class="nestali-list">
+<li><a class="osoba-ime" href="/nestale-osobe-403/403?osoba_id=7">Marko Horvat</a></li>
+<li><a class="osoba-ime" href="/nestale-osobe-403/403?osoba_id=8">Ana Kovač</a></li>
+</ul>`
+	fetcher := fakeFetcher{
+		"https://nestali.gov.hr/nestale-osobe-403/403?slovo=a&page=1": list,
+		// the site shows the last page again after the last page
+		"https://nestali.gov.hr/nestale-osobe-403/403?slovo=a&page=2": list,
+		"https://nestali.gov.hr/nestale-osobe-403/403?slovo=b&page=1": `<ul class="nestali-list"></ul>`,
+		"https://nestali.gov.hr/nestale-osobe-403/403?osoba_id=7":     croatiaProfile,
+		"https://nestali.gov.hr/nestale-osobe-403/403?osoba_id=8":     strings.NewReplacer(`<div class="menuLeftPhoto"><img src="/images/7.jpg"></div>`, "", "Marko", "Ana").Replace(croatiaProfile),
+		"https://nestali.gov.hr/images/7.jpg":                         string(testJPEG(t)),
+	}
+
+	deps := testDependencies(t, db, def, fetcher)
+	run := NewScraper(deps, def).Run()
+	assert.True(t, run.Complete)
+	assert.Equal(t, 2, run.Seen)
+	assert.Equal(t, 2, run.Created)
+
+	source := NewSource(db, deps.Images, def)
+	records, err := source.Records()
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "7", records[0].ItemID)
+	assert.Equal(t, "Marko", records[0].Person.Name)
+	assert.Equal(t, "Horvat", records[0].Person.LastName)
+	assert.Equal(t, 1, records[0].ParserVersion)
+
+	img, extension, err := source.Image("7")
+	assert.Nil(t, err)
+	assert.Equal(t, "jpg", extension)
+	assert.Equal(t, testJPEG(t), img)
+
+	raw, err := deps.Store.FindRaw(records[1].RawID)
+	assert.Nil(t, err)
+	assert.Equal(t, scraper.ImageNotOnPage, raw.MissingImageReason)
+
+	entries, err := source.Added(10)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "hr", entries[0].Country)
+}
+
+func TestSelectAndNext(t *testing.T) {
+	def, err := LoadDefinitionFile("testdata/romania.yaml")
+	assert.Nil(t, err)
+	def.Expect.MinListed = 2
+
+	profile := `<div class="descDetaliiDisparuti"><span>Nume</span><span>Popescu</span><span>Prenume</span><span>Ion</span></div>
+<div class="semnalmenteDisparuti"><p>înălțime 1,70 m, <b>păr șaten</b></p></div>`
+	fetcher := fakeFetcher{
+		"https://www.politiaromana.ro/ro/persoane-disparute":                `<select id="num_page"><option>1</option><option>2</option></select>`,
+		"https://www.politiaromana.ro/ro/persoane-disparute&page=1":         `<div class="contentList"><div class="boxPoza"><a href="/ro/persoane-disparute/ion-popescu-11"></a></div></div>`,
+		"https://www.politiaromana.ro/ro/persoane-disparute&page=2":         `<div class="contentList"><div class="boxPoza"><a href="/ro/persoane-disparute/ana-pop-12"></a></div></div>`,
+		"https://www.politiaromana.ro/ro/persoane-disparute/ion-popescu-11": profile,
+		"https://www.politiaromana.ro/ro/persoane-disparute/ana-pop-12":     strings.NewReplacer("Popescu", "Pop", "Ion", "Ana").Replace(profile),
+	}
+
+	db := testDB(t, def)
+	deps := testDependencies(t, db, def, fetcher)
+	run := NewScraper(deps, def).Run()
+	assert.Equal(t, 2, run.Created)
+
+	records, err := NewSource(db, deps.Images, def).Records()
+	assert.Nil(t, err)
+	assert.Equal(t, "11", records[0].ItemID)
+	assert.Equal(t, "Ion", records[0].Person.Name)
+	assert.Equal(t, "înălțime 1,70 m, păr șaten", records[0].Person.Description)
+
+	// the same site with a link to the next page, the last page links back to the first
+	def.List.Pagination = Pagination{Type: PaginationNext, Next: "a.next", FirstPage: 1, MaxPages: DefaultMaxPages}
+	fetcher["https://www.politiaromana.ro/ro/persoane-disparute&page=1"] += `<a class="next" href="/ro/persoane-disparute&page=2">›</a>`
+	fetcher["https://www.politiaromana.ro/ro/persoane-disparute&page=2"] += `<a class="next" href="/ro/persoane-disparute&page=1">›</a>`
+
+	run = NewScraper(deps, def).Run()
+	assert.True(t, run.Complete)
+	assert.Equal(t, 2, run.Seen)
+	assert.Equal(t, 2, run.Unchanged)
+}
+
+func TestNothingListed(t *testing.T) {
+	def, err := LoadDefinitionFile("testdata/croatia.yaml")
+	assert.Nil(t, err)
+	// a run that lists no one is a changed layout even without min_listed
+	def.Expect.MinListed = 0
+	db := testDB(t, def)
+
+	fetcher := fakeFetcher{
+		"https://nestali.gov.hr/nestale-osobe-403/403?slovo=a&page=1": `<ul class="nestali-list"><li><a class="osoba-ime" href="/nestale-osobe-403/403?osoba_id=7">Marko Horvat</a></li></ul>`,
+		"https://nestali.gov.hr/nestale-osobe-403/403?slovo=a&page=2": `<ul class="nestali-list"></ul>`,
+		"https://nestali.gov.hr/nestale-osobe-403/403?slovo=b&page=1": `<ul class="nestali-list"></ul>`,
+		"https://nestali.gov.hr/nestale-osobe-403/403?osoba_id=7":     croatiaProfile,
+	}
+
+	deps := testDependencies(t, db, def, fetcher)
+	run := NewScraper(deps, def).Run()
+	assert.False(t, run.LayoutChanged)
+	assert.Equal(t, 1, run.Created)
+
+	// the items selector does not match anymore a day later, the person is not removed
+	deps.Clock = func() time.Time { return time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC) }
+	fetcher["https://nestali.gov.hr/nestale-osobe-403/403?slovo=a&page=1"] = `<ul class="list"><li><a href="/nestale-osobe-403/403?osoba_id=7">Marko Horvat</a></li></ul>`
+	run = NewScraper(deps, def).Run()
+	assert.True(t, run.LayoutChanged)
+	assert.Zero(t, run.Removed)
+
+	entries, err := NewSource(db, deps.Images, def).Removed(10)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"encoding/json"
+	"golang.org/x/net/html"
+	"missing-persons-scrapper/pkg/htmlParser"
+	"regexp"
+	"strings"
+)
+
+// What is saved of a person page, the raw data of a row.
+type personData struct {
+	Tokens []string          `json:"tokens"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// Returns the tokens and fields of the person page and the src of the image, empty if it has none.
+func (d *Definition) parse(doc *html.Node) (personData, string, error) {
+	data := personData{Tokens: make([]string, 0)}
+	for _, sel := range d.Person.Tokens {
+		nodes, err := htmlParser.Query(doc, sel)
+		if err != nil {
+			return personData{}, "", err
+		}
+
+		// the text an element starts with, like the country scrapers take it
+		for _, n := range nodes {
+			if n.FirstChild != nil && n.FirstChild.Type == html.TextNode && strings.TrimSpace(n.FirstChild.Data) != "" {
+				data.Tokens = append(data.Tokens, n.FirstChild.Data)
+			}
+		}
+	}
+
+	for field, sel := range d.Person.Fields {
+		n, err := htmlParser.Find(doc, sel)
+		if err != nil {
+			return personData{}, "", err
+		}
+
+		if n != nil {
+			if data.Fields == nil {
+				data.Fields = make(map[string]string)
+			}
+			data.Fields[field] = strings.TrimSpace(text(n))
+		}
+	}
+
+	if d.Person.Image == "" {
+		return data, "", nil
+	}
+
+	img, err := htmlParser.Find(doc, d.Person.Image)
+	if err != nil || img == nil {
+		return data, "", err
+	}
+
+	return data, htmlParser.Attr(d.Person.ImageAttr, img.Attr), nil
+}
+
+// The person of a saved row.
+func (d *Definition) person(data []byte) htmlParser.RawPerson {
+	var saved personData
+	_ = json.Unmarshal(data, &saved)
+
+	person := htmlParser.NewRawPersonFromTokens(saved.Tokens, d.Labels)
+	for field, value := range saved.Fields {
+		person.Set(field, value)
+	}
+
+	return person
+}
+
+func (d *Definition) idPattern() *regexp.Regexp {
+	return d.id
+}
+
+// the text of the node and of everything in it
+func text(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	buff := &strings.Builder{}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		buff.WriteString(text(c))
+	}
+
+	return buff.String()
+}
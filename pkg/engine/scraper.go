@@ -0,0 +1,334 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/net/html"
+	"missing-persons-scrapper/pkg/htmlParser"
+	"missing-persons-scrapper/pkg/scraper"
+	"missing-persons-scrapper/pkg/storage"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scrapes the site of a Definition.
+type Scraper struct {
+	scraper.Dependencies
+	def *Definition
+}
+
+func NewScraper(deps scraper.Dependencies, def *Definition) *Scraper {
+	return &Scraper{Dependencies: deps, def: def}
+}
+
+func (s *Scraper) Country() string {
+	return s.def.Country
+}
+
+// The state of a run, what the list pages found is written as they are read.
+type session struct {
+	*Scraper
+	run    storage.Run
+	writer *storage.Writer
+	layout *scraper.Layout
+	images []scraper.PendingImage
+	// only a run that went through every list page and person can tell which persons were removed
+	complete bool
+}
+
+func (s *Scraper) Run() storage.Run {
+	r := &session{
+		Scraper:  s,
+		run:      storage.Run{Country: s.def.Country, StartedAt: s.Clock()},
+		writer:   s.Writer(),
+		layout:   scraper.NewLayout(s.def.Expect),
+		images:   make([]scraper.PendingImage, 0),
+		complete: true,
+	}
+
+	finishArchive := s.BeginRun(&r.run)
+	defer finishArchive()
+
+	p := s.def.Pagination()
+	switch p.Type {
+	case PaginationLetters:
+		for _, letter := range p.Letters {
+			r.pages(p.FirstPage, func(page int) string { return s.listURL(letter, page) })
+		}
+	case PaginationSelect:
+		pages, err := s.pageNumbers()
+		if err != nil {
+			r.fail(fmt.Errorf("failed getting the pages: %w", err))
+		}
+
+		for _, page := range pages {
+			if r.stopped() {
+				break
+			}
+
+			if links, err := s.list(s.listURL("", page)); err != nil {
+				r.fail(fmt.Errorf("failed to get list: page: %d: %w", page, err))
+			} else {
+				r.persons(links)
+			}
+		}
+	case PaginationNext:
+		r.follow(s.listURL("", p.FirstPage))
+	default:
+		if links, err := s.list(s.listURL("", p.FirstPage)); err != nil {
+			r.fail(fmt.Errorf("failed to get list: %w", err))
+		} else {
+			r.persons(links)
+		}
+	}
+
+	return r.finish()
+}
+
+/*
+*
+Reads the pages first, first + 1, ... until a page lists no persons. A page that lists the same persons as
+the page before ends the pages too, some sites show the last page for every page after it.
+*/
+func (r *session) pages(first int, pageURL func(page int) string) {
+	previous := ""
+	for page := first; page < first+r.def.Pagination().MaxPages; page++ {
+		if r.stopped() {
+			return
+		}
+
+		links, err := r.list(pageURL(page))
+		if err != nil {
+			r.fail(fmt.Errorf("failed to get list: %s: %w", pageURL(page), err))
+			return
+		}
+
+		if len(links) == 0 || strings.Join(links, " ") == previous {
+			return
+		}
+		previous = strings.Join(links, " ")
+
+		r.persons(links)
+	}
+
+	r.Logger.Printf("%s: stopped after %d pages of %s\n", r.def.Country, r.def.Pagination().MaxPages, pageURL(first))
+}
+
+// Reads the list page and the pages it links to as the next page, every page once.
+func (r *session) follow(pageURL string) {
+	visited := make(map[string]bool)
+	for pageURL != "" && !visited[pageURL] && len(visited) < r.def.Pagination().MaxPages && !r.stopped() {
+		visited[pageURL] = true
+
+		doc, err := r.document(pageURL)
+		if err != nil {
+			r.fail(fmt.Errorf("failed to get list: %s: %w", pageURL, err))
+			return
+		}
+
+		links, err := r.links(doc, pageURL)
+		if err != nil {
+			r.fail(fmt.Errorf("failed to get list: %s: %w", pageURL, err))
+			return
+		}
+		r.persons(links)
+
+		next, err := htmlParser.Find(doc, r.def.Pagination().Next)
+		if err != nil || next == nil {
+			return
+		}
+
+		pageURL = resolve(pageURL, htmlParser.Attr("href", next.Attr))
+	}
+}
+
+// Fetches the pages of the persons and adds them to the writer.
+func (r *session) persons(links []string) {
+	r.layout.List(len(links))
+
+	for _, link := range links {
+		if r.stopped() {
+			return
+		}
+
+		personId, ok := r.personID(link)
+		if !ok {
+			r.fail(fmt.Errorf("no website id in %s", link))
+			continue
+		}
+
+		profile, err := htmlParser.GetPage(r.Fetcher, link)
+		if err != nil {
+			r.fail(fmt.Errorf("failed to get individual person page: %s: %w", link, err))
+			continue
+		}
+
+		seenAt := r.Clock()
+		if profile.Unchanged {
+			// the page is the same as on the last run, the person is only marked as seen
+			err = r.writer.AddSeen(personId, seenAt, func() error {
+				return r.process(personId, link, profile.Body, seenAt)
+			})
+		} else {
+			err = r.process(personId, link, profile.Body, seenAt)
+		}
+
+		// the writer counts the persons of a batch that failed
+		if err != nil {
+			r.Logger.Println(err)
+			r.complete = false
+		}
+
+		r.run.Seen++
+	}
+}
+
+// Parses the page of the person and adds the person to the writer if the page looks as expected.
+func (r *session) process(personId, personURL string, body []byte, seenAt time.Time) error {
+	doc, err := htmlParser.Parse(string(body))
+	if err != nil {
+		return fmt.Errorf("failed parsing %s: %w", personURL, err)
+	}
+
+	data, imageSrc, err := r.def.parse(doc)
+	if err != nil {
+		return fmt.Errorf("failed parsing %s: %w", personURL, err)
+	}
+
+	if err := r.layout.CheckPage(doc, data.Tokens); err != nil {
+		return fmt.Errorf("%s: %w", personURL, err)
+	}
+
+	b, _ := json.Marshal(data)
+	raw := storage.Raw{
+		Data:             b,
+		ItemID:           personId,
+		UniqueIdentifier: fmt.Sprintf("%x", sha256.Sum256(b)),
+		SourceURL:        personURL,
+		ParserVersion:    r.def.ParserVersion,
+		FirstSeen:        seenAt,
+		LastSeen:         seenAt,
+	}
+
+	return r.writer.AddRaw(raw, func(saved storage.Raw, _ storage.Outcome) {
+		if imageSrc == "" {
+			r.images = append(r.images, scraper.PendingImage{RawID: saved.ID, Reason: scraper.ImageNotOnPage})
+			return
+		}
+
+		r.images = append(r.images, scraper.PendingImage{RawID: saved.ID, URL: resolve(personURL, imageSrc)})
+	})
+}
+
+// Reports whether the pages do not look as expected any more, the run then stops.
+func (r *session) stopped() bool {
+	if r.layout.Changed() {
+		r.complete = false
+		return true
+	}
+
+	return false
+}
+
+func (r *session) fail(err error) {
+	r.Logger.Println(err)
+	r.complete = false
+	r.run.Failed++
+}
+
+// Writes what is left unless the layout changed, downloads the images and records the run.
+func (r *session) finish() storage.Run {
+	return r.FinishRun(r.run, r.writer, r.layout, &r.images, r.complete)
+}
+
+func (s *Scraper) listURL(letter string, page int) string {
+	return strings.NewReplacer("{letter}", url.QueryEscape(letter), "{page}", strconv.Itoa(page)).Replace(s.def.List.URL)
+}
+
+func (s *Scraper) document(pageURL string) (*html.Node, error) {
+	body, err := htmlParser.GetBody(s.Fetcher, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return htmlParser.Parse(string(body))
+}
+
+// Returns the absolute URLs of the person pages the list page links to.
+func (s *Scraper) list(pageURL string) ([]string, error) {
+	doc, err := s.document(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.links(doc, pageURL)
+}
+
+func (s *Scraper) links(doc *html.Node, pageURL string) ([]string, error) {
+	items, err := htmlParser.Query(doc, s.def.List.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]string, 0, len(items))
+	for _, item := range items {
+		if href := htmlParser.Attr(s.def.List.LinkAttr, item.Attr); href != "" {
+			links = append(links, resolve(pageURL, href))
+		}
+	}
+
+	return links, nil
+}
+
+// The numbers of the list pages, the texts of the options that are numbers.
+func (s *Scraper) pageNumbers() ([]int, error) {
+	doc, err := s.document(s.def.Pagination().PagesURL)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := htmlParser.Query(doc, s.def.Pagination().Options)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]int, 0, len(options))
+	for _, o := range options {
+		page, err := strconv.Atoi(strings.TrimSpace(text(o)))
+		if err != nil {
+			s.Logger.Println(fmt.Errorf("cannot convert page to number: %w", err))
+			continue
+		}
+
+		pages = append(pages, page)
+	}
+
+	return pages, nil
+}
+
+func (s *Scraper) personID(personURL string) (string, bool) {
+	match := s.def.idPattern().FindStringSubmatch(personURL)
+	if len(match) < 2 || match[1] == "" {
+		return "", false
+	}
+
+	return match[1], true
+}
+
+// the reference resolved against the page it is on, the reference itself if either is not a URL
+func resolve(pageURL, ref string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ref
+	}
+
+	r, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+
+	return base.ResolveReference(r).String()
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"gorm.io/gorm"
+	"missing-persons-scrapper/pkg/feed"
+	"missing-persons-scrapper/pkg/imagestore"
+	"missing-persons-scrapper/pkg/normalize"
+	"missing-persons-scrapper/pkg/persons"
+	"missing-persons-scrapper/pkg/storage"
+)
+
+// Source exposes the scrapped persons of a Definition to the API and to normalization.
+type Source struct {
+	feed.Source
+	db  *gorm.DB
+	def *Definition
+}
+
+func NewSource(db *gorm.DB, images imagestore.Store, def *Definition) Source {
+	return Source{Source: feed.NewSource(db, def.Tables(), images, def.Country, def.person), db: db, def: def}
+}
+
+// Returns the store of the tables of the definition in db, see migrations.UpTables.
+func NewStore(db *gorm.DB, def *Definition) storage.Store {
+	return storage.NewStore(db, def.Tables())
+}
+
+func (s Source) raws() *gorm.DB {
+	return s.db.Table(s.def.Tables().Raw)
+}
+
+// Records returns every raw row of the definition for normalization.
+func (s Source) Records() ([]persons.Record, error) {
+	var rows []storage.Raw
+	if res := s.raws().Find(&rows); res.Error != nil {
+		return nil, res.Error
+	}
+
+	images, err := s.CurrentImages()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]persons.Record, len(rows))
+	for i, r := range rows {
+		records[i] = persons.Record{
+			RawID:         r.ID,
+			ItemID:        r.ItemID,
+			SourceURL:     r.SourceURL,
+			FirstSeen:     r.FirstSeen,
+			RemovedAt:     r.RemovedAt,
+			ImageHash:     images[r.ID].Hash,
+			ImagePHash:    images[r.ID].PHash,
+			ParserVersion: r.ParserVersion,
+			Person:        normalize.NewPerson(s.def.person(r.Data)),
+		}
+	}
+
+	return records, nil
+}
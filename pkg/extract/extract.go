@@ -0,0 +1,235 @@
+package extract
+
+import (
+	"missing-persons-scrapper/pkg/htmlParser"
+	"missing-persons-scrapper/pkg/normalize"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Extraction struct {
+	Field      string
+	Value      string
+	Confidence float64
+}
+
+// phrases end on ";", "." or "," followed by a space, so decimal numbers ("1,70 m") stay in one phrase
+var phraseEnd = regexp.MustCompile(`[;.,](\s+|$)|\n`)
+
+/*
+*
+Extracts the fields from a free text description. Every phrase of the text is matched against the
+labels of all fields first, then against the patterns and at last against the words. Only the first
+value of a field is kept.
+
+A phrase that matches nothing, or only words, is appended to the value of a field that continues.
+*/
+func (r Rules) Extract(text string) []Extraction {
+	extractions := make([]Extraction, 0)
+	found := make(map[string]int)
+
+	add := func(field, value string, confidence float64) bool {
+		value = strings.Trim(value, " :-–,")
+		if value == "" {
+			return false
+		}
+
+		if _, ok := found[field]; ok {
+			return false
+		}
+
+		found[field] = len(extractions)
+		extractions = append(extractions, Extraction{Field: field, Value: value, Confidence: confidence})
+
+		return true
+	}
+
+	continuing := ""
+	for _, phrase := range phrases(text) {
+		folded := normalize.Fold(phrase)
+
+		if labeled := r.matchLabels(phrase, folded); len(labeled) > 0 {
+			continuing = ""
+			for _, l := range labeled {
+				if add(l.field, l.value, r.Confidence.Label) && l.continues {
+					continuing = l.field
+				}
+			}
+			continue
+		}
+
+		if field, value, ok := r.matchPattern(phrase, folded); ok {
+			continuing = ""
+			add(field, value, r.Confidence.Pattern)
+			continue
+		}
+
+		// colours in a list of clothes ("blugi albaștri") are not eye colours, so only labels end the list
+		if continuing != "" {
+			i := found[continuing]
+			extractions[i].Value = extractions[i].Value + ", " + strings.TrimSpace(phrase)
+			continue
+		}
+
+		if field, ok := r.matchWord(folded); ok {
+			add(field, phrase, r.Confidence.Word)
+		}
+	}
+
+	return extractions
+}
+
+/*
+*
+Runs the extraction over the text and sets the fields of the person that are still empty, together with
+the confidence of each extracted field.
+*/
+func (r Rules) Apply(person *htmlParser.RawPerson, text string) {
+	if person.Confidence == nil {
+		person.Confidence = make(map[string]float64)
+	}
+
+	for _, e := range r.Extract(text) {
+		if person.Get(e.Field) != "" {
+			continue
+		}
+
+		person.Set(e.Field, e.Value)
+		person.Confidence[e.Field] = e.Confidence
+	}
+}
+
+type labeled struct {
+	field      string
+	value      string
+	continues  bool
+	start, end int
+}
+
+/*
+*
+Finds every label in the phrase. The value of a label is the text up to the next label, so a phrase
+like "păr șaten și ochi căprui" gives both the hair and the eye colour.
+*/
+func (r Rules) matchLabels(phrase, folded string) []labeled {
+	found := make([]labeled, 0)
+	for _, f := range r.Fields {
+		for _, label := range f.labels {
+			idx := wordIndex(folded, label)
+			if idx < 0 {
+				continue
+			}
+
+			l := labeled{field: f.Field, continues: f.Continues, start: idx, end: idx + utf8.RuneCountInString(label)}
+			if f.KeepLabel {
+				l.end = idx
+			}
+
+			found = append(found, l)
+			break
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].start < found[j].start
+	})
+
+	runes := []rune(phrase)
+	for i := range found {
+		end := len(runes)
+		if i+1 < len(found) {
+			end = found[i+1].start
+		}
+
+		if found[i].end > end {
+			found[i].end = end
+		}
+
+		found[i].value = trimConnectors(string(runes[found[i].end:end]))
+	}
+
+	return found
+}
+
+func (r Rules) matchPattern(phrase, folded string) (string, string, bool) {
+	for _, f := range r.Fields {
+		for _, p := range f.patterns {
+			m := p.FindStringSubmatchIndex(folded)
+			if m == nil || len(m) < 4 || m[2] < 0 {
+				continue
+			}
+
+			// the folded phrase has the same runes as the original one, so the rune positions match
+			start := utf8.RuneCountInString(folded[:m[2]])
+			end := utf8.RuneCountInString(folded[:m[3]])
+
+			return f.Field, string([]rune(phrase)[start:end]), true
+		}
+	}
+
+	return "", "", false
+}
+
+func (r Rules) matchWord(folded string) (string, bool) {
+	for _, f := range r.Fields {
+		for _, w := range f.words {
+			if wordIndex(folded, w) >= 0 {
+				return f.Field, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// rune position of the first occurrence of word in text that is not part of another word
+func wordIndex(text, word string) int {
+	runes := []rune(text)
+	w := []rune(word)
+
+	for i := 0; i+len(w) <= len(runes); i++ {
+		if string(runes[i:i+len(w)]) != word {
+			continue
+		}
+
+		if i > 0 && isWordRune(runes[i-1]) {
+			continue
+		}
+
+		if i+len(w) < len(runes) && isWordRune(runes[i+len(w)]) {
+			continue
+		}
+
+		return i
+	}
+
+	return -1
+}
+
+// removes a trailing "și" (and) that connected the value to the next label
+func trimConnectors(value string) string {
+	value = strings.TrimSpace(value)
+	for _, c := range []string{" și", " şi", " si"} {
+		value = strings.TrimSuffix(value, c)
+	}
+
+	return value
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func phrases(text string) []string {
+	result := make([]string, 0)
+	for _, p := range phraseEnd.Split(text, -1) {
+		if p = strings.TrimSpace(p); p != "" {
+			result = append(result, p)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,74 @@
+package extract
+
+import (
+	"github.com/stretchr/testify/assert"
+	"missing-persons-scrapper/pkg/htmlParser"
+	"testing"
+)
+
+func romanianRules(t *testing.T) Rules {
+	rules, err := LoadRulesFile("../countries/romania/description_rules.yaml")
+	assert.Nil(t, err)
+
+	return rules
+}
+
+func TestExtract(t *testing.T) {
+	rules := romanianRules(t)
+
+	text := "Semnalmente: înălțime 1,70 m, constituție atletică, păr șaten și ochi căprui, " +
+		"tatuaj pe antebrațul stâng. La data dispariției era îmbrăcat cu geacă neagră, blugi albaștri, adidași albi."
+
+	extracted := make(map[string]Extraction)
+	for _, e := range rules.Extract(text) {
+		extracted[e.Field] = e
+	}
+
+	assert.Equal(t, "1,70 m", extracted["Height"].Value)
+	assert.Equal(t, 0.9, extracted["Height"].Confidence)
+	assert.Equal(t, "atletică", extracted["Build"].Value)
+	assert.Equal(t, "șaten", extracted["Hair"].Value)
+	assert.Equal(t, "căprui", extracted["EyeColor"].Value)
+	assert.Equal(t, "tatuaj pe antebrațul stâng", extracted["DistinguishingMarks"].Value)
+	assert.Equal(t, "geacă neagră, blugi albaștri, adidași albi", extracted["Clothing"].Value)
+}
+
+func TestExtractWithoutLabels(t *testing.T) {
+	rules := romanianRules(t)
+
+	extracted := make(map[string]Extraction)
+	for _, e := range rules.Extract("are 1,65 m, este slabă, blondă") {
+		extracted[e.Field] = e
+	}
+
+	assert.Equal(t, "1,65 m", extracted["Height"].Value)
+	assert.Equal(t, 0.7, extracted["Height"].Confidence)
+	assert.Equal(t, "este slabă", extracted["Build"].Value)
+	assert.Equal(t, 0.6, extracted["Build"].Confidence)
+	assert.Equal(t, "blondă", extracted["Hair"].Value)
+}
+
+func TestLongestLabel(t *testing.T) {
+	rules := romanianRules(t)
+
+	extracted := make(map[string]Extraction)
+	for _, e := range rules.Extract("ochii de culoare căprui, constituție corpolentă") {
+		extracted[e.Field] = e
+	}
+
+	assert.Equal(t, "căprui", extracted["EyeColor"].Value)
+	assert.Equal(t, "corpolentă", extracted["Build"].Value)
+}
+
+func TestApplyKeepsLabeledFields(t *testing.T) {
+	rules := romanianRules(t)
+
+	person := htmlParser.NewRawPerson()
+	person.Height = "172 cm"
+	rules.Apply(&person, "înălțime 1,70 m, ochi verzi")
+
+	assert.Equal(t, "172 cm", person.Height)
+	assert.Equal(t, "verzi", person.EyeColor)
+	assert.Equal(t, 0.9, person.Confidence["EyeColor"])
+	assert.NotContains(t, person.Confidence, "Height")
+}
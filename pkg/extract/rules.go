@@ -0,0 +1,92 @@
+package extract
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"missing-persons-scrapper/pkg/normalize"
+	"os"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+/*
+*
+Rules for a single RawPerson field.
+
+Labels are words that introduce the value ("înălțime 1,70 m"), the text after the label is the value. The
+longest label found in a phrase is used.
+Words are values by themselves ("constituție atletică", "păr șaten"), the whole phrase becomes the value.
+Patterns are regular expressions for values that have no label, the first group is the value.
+
+KeepLabel keeps the label in the value ("tatuaj pe brațul stâng") and Continues makes the value
+continue over the following phrases until another field starts (lists of clothing).
+*/
+type FieldRules struct {
+	Field     string   `yaml:"field"`
+	Labels    []string `yaml:"labels"`
+	Words     []string `yaml:"words"`
+	Patterns  []string `yaml:"patterns"`
+	KeepLabel bool     `yaml:"keep_label"`
+	Continues bool     `yaml:"continues"`
+
+	labels   []string
+	words    []string
+	patterns []*regexp.Regexp
+}
+
+type Confidence struct {
+	Label   float64 `yaml:"label"`
+	Pattern float64 `yaml:"pattern"`
+	Word    float64 `yaml:"word"`
+}
+
+type Rules struct {
+	Confidence Confidence   `yaml:"confidence"`
+	Fields     []FieldRules `yaml:"fields"`
+}
+
+func LoadRulesFile(path string) (Rules, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Rules{}, err
+	}
+
+	return LoadRules(b)
+}
+
+func LoadRules(b []byte) (Rules, error) {
+	var rules Rules
+	if err := yaml.Unmarshal(b, &rules); err != nil {
+		return Rules{}, fmt.Errorf("invalid extraction rules: %w", err)
+	}
+
+	if rules.Confidence == (Confidence{}) {
+		rules.Confidence = Confidence{Label: 0.9, Pattern: 0.7, Word: 0.6}
+	}
+
+	for i, f := range rules.Fields {
+		for _, l := range f.Labels {
+			rules.Fields[i].labels = append(rules.Fields[i].labels, normalize.Fold(l))
+		}
+		// the first label found is used, "ochii de culoare" is found before "ochi"
+		sort.SliceStable(rules.Fields[i].labels, func(a, b int) bool {
+			return utf8.RuneCountInString(rules.Fields[i].labels[a]) > utf8.RuneCountInString(rules.Fields[i].labels[b])
+		})
+
+		for _, w := range f.Words {
+			rules.Fields[i].words = append(rules.Fields[i].words, normalize.Fold(w))
+		}
+
+		for _, p := range f.Patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return Rules{}, fmt.Errorf("invalid pattern for %s: %w", f.Field, err)
+			}
+
+			rules.Fields[i].patterns = append(rules.Fields[i].patterns, re)
+		}
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,198 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"sort"
+	"strings"
+	"time"
+)
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published"`
+	Links     []atomLink  `xml:"link"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type rssGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	Guid        rssGuid       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Description string        `xml:"description"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+func WriteAtom(w io.Writer, f Feed) error {
+	atom := atomFeed{
+		ID:      f.ID,
+		Title:   f.Title,
+		Updated: f.Updated.UTC().Format(time.RFC3339),
+		Links:   []atomLink{{Href: f.Link, Rel: "self"}},
+	}
+
+	for _, e := range f.Entries {
+		links := []atomLink{{Href: e.Link, Rel: "alternate", Type: "text/html"}}
+		if e.ImageURL != "" {
+			links = append(links, atomLink{Href: e.ImageURL, Rel: "enclosure", Type: e.imageType()})
+		}
+
+		published := e.Published.UTC().Format(time.RFC3339)
+		atom.Entries = append(atom.Entries, atomEntry{
+			ID:        e.ID,
+			Title:     e.Title,
+			Updated:   published,
+			Published: published,
+			Links:     links,
+			Content:   atomContent{Type: "html", Body: summary(e)},
+		})
+	}
+
+	return write(w, atom)
+}
+
+func WriteRSS(w io.Writer, f Feed) error {
+	rss := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Title,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	for _, e := range f.Entries {
+		item := rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			Guid:        rssGuid{IsPermaLink: false, Value: e.ID},
+			PubDate:     e.Published.UTC().Format(time.RFC1123Z),
+			Description: summary(e),
+		}
+
+		if e.ImageURL != "" {
+			item.Enclosure = &rssEnclosure{URL: e.ImageURL, Type: e.imageType()}
+		}
+
+		rss.Channel.Items = append(rss.Channel.Items, item)
+	}
+
+	return write(w, rss)
+}
+
+/*
+*
+Merges the entries of multiple sources into one list, newest first, and keeps at most limit
+entries. Used for the global feeds.
+*/
+func Merge(limit int, lists ...[]Entry) []Entry {
+	merged := make([]Entry, 0)
+	for _, l := range lists {
+		merged = append(merged, l...)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Published.After(merged[j].Published)
+	})
+
+	if limit > 0 && len(merged) > limit {
+		merged = merged[:limit]
+	}
+
+	return merged
+}
+
+func EntryID(country, itemID string) string {
+	return fmt.Sprintf("urn:missing-persons:%s:%s", country, itemID)
+}
+
+func summary(e Entry) string {
+	parts := make([]string, 0)
+	if e.ImageURL != "" {
+		parts = append(parts, fmt.Sprintf(`<img src="%s" alt="%s"/>`, html.EscapeString(e.ImageURL), html.EscapeString(e.Title)))
+	}
+
+	if e.DOD != "" {
+		parts = append(parts, fmt.Sprintf("<p>Date of disappearance: %s</p>", html.EscapeString(e.DOD)))
+	}
+
+	if e.POD != "" {
+		parts = append(parts, fmt.Sprintf("<p>Place of disappearance: %s</p>", html.EscapeString(e.POD)))
+	}
+
+	parts = append(parts, fmt.Sprintf(`<p><a href="%s">Official page</a></p>`, html.EscapeString(e.Link)))
+
+	return strings.Join(parts, "")
+}
+
+func (e Entry) imageType() string {
+	t := mime.TypeByExtension("." + e.ImageExtension)
+	if t == "" {
+		return "image/jpeg"
+	}
+
+	return t
+}
+
+func write(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	return enc.Encode(v)
+}
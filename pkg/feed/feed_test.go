@@ -0,0 +1,69 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func testEntries() []Entry {
+	return []Entry{
+		{
+			ID:             EntryID("hr", "1"),
+			Country:        "hr",
+			ItemID:         "1",
+			Title:          "Marko Marić",
+			Link:           "https://nestali.gov.hr/nestale-osobe-403/403?osoba_id=1",
+			ImageURL:       "http://localhost:8080/images/hr/1",
+			ImageExtension: "png",
+			DOD:            "12.03.2020.",
+			POD:            "Zagreb",
+			Published:      time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:        EntryID("ro", "2"),
+			Country:   "ro",
+			ItemID:    "2",
+			Title:     "Ion Popescu",
+			Link:      "https://www.politiaromana.ro/ro/persoane-disparute/ion-popescu-2",
+			Published: time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestMerge(t *testing.T) {
+	entries := testEntries()
+	merged := Merge(1, entries[:1], entries[1:])
+
+	assert.Len(t, merged, 1)
+	assert.Equal(t, "ro", merged[0].Country)
+}
+
+func TestWriteAtom(t *testing.T) {
+	f := NewFeed("urn:test", "Test", "http://localhost:8080/feeds/all/new/atom", testEntries())
+
+	buff := &bytes.Buffer{}
+	assert.Nil(t, WriteAtom(buff, f))
+
+	var parsed atomFeed
+	assert.Nil(t, xml.Unmarshal(buff.Bytes(), &parsed))
+	assert.Equal(t, "2024-01-03T10:00:00Z", parsed.Updated)
+	assert.Len(t, parsed.Entries, 2)
+	assert.Equal(t, "image/png", parsed.Entries[0].Links[1].Type)
+	assert.Contains(t, parsed.Entries[0].Content.Body, "Place of disappearance: Zagreb")
+}
+
+func TestWriteRSS(t *testing.T) {
+	f := NewFeed("urn:test", "Test", "http://localhost:8080/feeds/all/new/rss", testEntries())
+
+	buff := &bytes.Buffer{}
+	assert.Nil(t, WriteRSS(buff, f))
+
+	var parsed rssFeed
+	assert.Nil(t, xml.Unmarshal(buff.Bytes(), &parsed))
+	assert.Len(t, parsed.Channel.Items, 2)
+	assert.NotNil(t, parsed.Channel.Items[0].Enclosure)
+	assert.Nil(t, parsed.Channel.Items[1].Enclosure)
+}
@@ -0,0 +1,53 @@
+package feed
+
+import "time"
+
+const (
+	KindAdded   = "new"
+	KindRemoved = "removed"
+)
+
+/*
+*
+A single missing person in a feed. Published is the first time the person was seen for
+the "new" feeds and the time the person was removed from the official site for the
+"removed" feeds.
+*/
+type Entry struct {
+	ID        string
+	Country   string
+	ItemID    string
+	Title     string
+	Link      string
+	ImageURL  string
+	DOD       string
+	POD       string
+	Published time.Time
+
+	ImageExtension string
+}
+
+type Feed struct {
+	ID      string
+	Title   string
+	Link    string
+	Updated time.Time
+	Entries []Entry
+}
+
+func NewFeed(id, title, link string, entries []Entry) Feed {
+	updated := time.Time{}
+	for _, e := range entries {
+		if e.Published.After(updated) {
+			updated = e.Published
+		}
+	}
+
+	return Feed{
+		ID:      id,
+		Title:   title,
+		Link:    link,
+		Updated: updated,
+		Entries: entries,
+	}
+}
@@ -0,0 +1,246 @@
+package feed
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"missing-persons-scrapper/pkg/htmlParser"
+	"missing-persons-scrapper/pkg/imagestore"
+	"missing-persons-scrapper/pkg/storage"
+	"sort"
+	"time"
+)
+
+/*
+*
+The feeds and the images of the persons scrapped into the tables of a country. Every country has the same
+tables, they only differ in their names and in how the saved data is read as a person.
+*/
+type Source struct {
+	db      *gorm.DB
+	tables  storage.Tables
+	images  imagestore.Store
+	country string
+	// the person of the data of a raw row
+	person func(data []byte) htmlParser.RawPerson
+}
+
+func NewSource(db *gorm.DB, tables storage.Tables, images imagestore.Store, country string, person func(data []byte) htmlParser.RawPerson) Source {
+	return Source{db: db, tables: tables, images: images, country: country, person: person}
+}
+
+func (s Source) Country() string {
+	return s.country
+}
+
+func (s Source) raws() *gorm.DB {
+	return s.db.Table(s.tables.Raw)
+}
+
+func (s Source) imageRows() *gorm.DB {
+	return s.db.Table(s.tables.Images)
+}
+
+/*
+*
+The persons first seen last. A person has a row for every version of the data on the official page, the
+entry is the newest version published when the first version was seen.
+*/
+func (s Source) Added(limit int) ([]Entry, error) {
+	var first []storage.Raw
+	res := s.raws().
+		Select("item_id", "first_seen").
+		Where(fmt.Sprintf("first_seen = (SELECT MIN(o.first_seen) FROM %s o WHERE o.item_id = %s.item_id)", s.tables.Raw, s.tables.Raw)).
+		Order("first_seen DESC").Limit(limit).
+		Find(&first)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	firstSeen := make(map[string]time.Time, len(first))
+	itemIDs := make([]string, 0, len(first))
+	for _, r := range first {
+		if _, ok := firstSeen[r.ItemID]; !ok {
+			firstSeen[r.ItemID] = r.FirstSeen
+			itemIDs = append(itemIDs, r.ItemID)
+		}
+	}
+
+	newest, err := s.newest(s.raws().Where("item_id IN ?", itemIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]storage.Raw, 0, len(itemIDs))
+	for _, id := range itemIDs {
+		if r, ok := newest[id]; ok {
+			records = append(records, r)
+		}
+	}
+
+	return s.entries(records, func(r storage.Raw) Entry {
+		e := s.entry(r)
+		e.Published = firstSeen[r.ItemID]
+		return e
+	})
+}
+
+/*
+*
+The persons removed from the official site last, a person is removed when none of its rows is on the site.
+An older version of the data that was replaced by a newer one does not remove the person.
+*/
+func (s Source) Removed(limit int) ([]Entry, error) {
+	query := s.raws().
+		Where(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s o WHERE o.item_id = %s.item_id AND o.removed_at IS NULL)", s.tables.Raw, s.tables.Raw)).
+		Order("removed_at DESC").Limit(limit)
+
+	newest, err := s.newest(query)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]storage.Raw, 0, len(newest))
+	for _, r := range newest {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].RemovedAt.After(*records[j].RemovedAt)
+	})
+
+	return s.entries(records, func(r storage.Raw) Entry {
+		e := s.entry(r)
+		e.ID = fmt.Sprintf("%s:removed", e.ID)
+		e.Published = *r.RemovedAt
+		return e
+	})
+}
+
+// the newest row of every website id the query finds, the one seen last, by website id
+func (s Source) newest(query *gorm.DB) (map[string]storage.Raw, error) {
+	var rows []storage.Raw
+	res := query.
+		Where(fmt.Sprintf("last_seen = (SELECT MAX(o.last_seen) FROM %s o WHERE o.item_id = %s.item_id)", s.tables.Raw, s.tables.Raw)).
+		Order("id").
+		Find(&rows)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	newest := make(map[string]storage.Raw, len(rows))
+	for _, r := range rows {
+		newest[r.ItemID] = r
+	}
+
+	return newest, nil
+}
+
+// Image returns the current version of the image of the person with the website id itemID and its extension.
+func (s Source) Image(itemID string) ([]byte, string, error) {
+	img, err := s.currentImage(itemID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// an image saved before the image store existed that was not moved yet
+	if len(img.Blob) > 0 {
+		return img.Blob, img.Extension, nil
+	}
+
+	blob, err := s.images.Get(img.Hash)
+	return blob, img.Extension, err
+}
+
+// ImageHash returns the image store key of the current image of the person with the website id itemID.
+func (s Source) ImageHash(itemID string) (string, error) {
+	img, err := s.currentImage(itemID)
+	return img.Hash, err
+}
+
+func (s Source) currentImage(itemID string) (storage.Image, error) {
+	var img storage.Image
+	res := s.imageRows().
+		Joins(fmt.Sprintf("JOIN %s ON %s.id = %s.item_id", s.tables.Raw, s.tables.Raw, s.tables.Images)).
+		Where(fmt.Sprintf("%s.item_id = ?", s.tables.Raw), itemID).
+		Order(fmt.Sprintf("%s.checked_at DESC, %s.id DESC", s.tables.Images, s.tables.Images)).
+		Select(fmt.Sprintf("%s.*", s.tables.Images)).
+		First(&img)
+
+	return img, res.Error
+}
+
+// CurrentImages returns the current image of every raw row that has one in the image store, by raw row id.
+func (s Source) CurrentImages() (map[int]storage.Image, error) {
+	var images []storage.Image
+	res := s.imageRows().Select("item_id", "hash", "phash").Where("hash IS NOT NULL").Order("checked_at").Order("id").Find(&images)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	current := make(map[int]storage.Image, len(images))
+	for _, img := range images {
+		current[img.ItemID] = img
+	}
+
+	return current, nil
+}
+
+// ImageHashes returns the sha256 of the current image of every raw row that has one, by raw row id.
+func (s Source) ImageHashes() (map[int]string, error) {
+	images, err := s.CurrentImages()
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[int]string, len(images))
+	for id, img := range images {
+		hashes[id] = img.Hash
+	}
+
+	return hashes, nil
+}
+
+func (s Source) entry(r storage.Raw) Entry {
+	person := s.person(r.Data)
+
+	title := person.FullName()
+	if title == "" {
+		title = fmt.Sprintf("Missing person %s", r.ItemID)
+	}
+
+	return Entry{
+		ID:      EntryID(s.country, r.ItemID),
+		Country: s.country,
+		ItemID:  r.ItemID,
+		Title:   title,
+		Link:    r.SourceURL,
+		DOD:     person.DOD,
+		POD:     person.POD,
+	}
+}
+
+func (s Source) entries(records []storage.Raw, toEntry func(r storage.Raw) Entry) ([]Entry, error) {
+	ids := make([]int, len(records))
+	for i, r := range records {
+		ids[i] = r.ID
+	}
+
+	var images []storage.Image
+	// the current version is the last one
+	res := s.imageRows().Select("item_id", "extension").Where("item_id IN ?", ids).Order("checked_at").Order("id").Find(&images)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	extensions := make(map[int]string)
+	for _, img := range images {
+		extensions[img.ItemID] = img.Extension
+	}
+
+	entries := make([]Entry, len(records))
+	for i, r := range records {
+		entries[i] = toEntry(r)
+		entries[i].ImageExtension = extensions[r.ID]
+	}
+
+	return entries, nil
+}
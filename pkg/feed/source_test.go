@@ -0,0 +1,79 @@
+package feed
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"missing-persons-scrapper/pkg/htmlParser"
+	"missing-persons-scrapper/pkg/migrations"
+	"missing-persons-scrapper/pkg/storage"
+	"testing"
+	"time"
+)
+
+func testRaw(itemID, name string, seenAt time.Time) storage.Raw {
+	data, _ := json.Marshal(name)
+
+	return storage.Raw{
+		Data:             data,
+		ItemID:           itemID,
+		UniqueIdentifier: itemID + name,
+		FirstSeen:        seenAt,
+		LastSeen:         seenAt,
+	}
+}
+
+func TestSourceChangedPerson(t *testing.T) {
+	db, err := storage.Open(storage.Config{Driver: storage.DriverSQLite, Path: ":memory:"})
+	assert.Nil(t, err)
+	_, err = migrations.Up(db)
+	assert.Nil(t, err)
+
+	tables := storage.Tables{Raw: "croatia_scrapped", Images: "croatia_images"}
+	store := storage.NewStore(db, tables)
+	source := NewSource(db, tables, nil, "hr", func(data []byte) htmlParser.RawPerson {
+		person := htmlParser.NewRawPerson()
+		var name string
+		_ = json.Unmarshal(data, &name)
+		person.Set("Name", name)
+		return person
+	})
+
+	first := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	_, err = store.UpsertRaws([]storage.Raw{testRaw("7", "Marko", first), testRaw("8", "Ana", first)})
+	assert.Nil(t, err)
+
+	// the page of the first person changed, the other one did not
+	second := first.Add(24 * time.Hour)
+	_, err = store.UpsertRaws([]storage.Raw{testRaw("7", "Mark", second)})
+	assert.Nil(t, err)
+	_, err = store.MarkSeen([]string{"8"}, second)
+	assert.Nil(t, err)
+	removed, err := store.MarkRemoved(second)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), removed)
+
+	added, err := source.Added(10)
+	assert.Nil(t, err)
+	assert.Len(t, added, 2)
+	for _, e := range added {
+		assert.True(t, e.Published.Equal(first))
+	}
+	assert.ElementsMatch(t, []string{"Mark", "Ana"}, []string{added[0].Title, added[1].Title})
+
+	entries, err := source.Removed(10)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+
+	// the first person is not on the site anymore
+	third := second.Add(24 * time.Hour)
+	_, err = store.MarkSeen([]string{"8"}, third)
+	assert.Nil(t, err)
+	_, err = store.MarkRemoved(third)
+	assert.Nil(t, err)
+
+	entries, err = source.Removed(10)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "7", entries[0].ItemID)
+	assert.Equal(t, "Mark", entries[0].Title)
+}
@@ -0,0 +1,112 @@
+package geocode
+
+import (
+	"bufio"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// number of tab separated columns of a GeoNames "geoname" table row
+const geonamesColumns = 19
+
+/*
+*
+Parses a GeoNames country extract (tab separated, one place per line) and keeps the populated places and
+administrative areas.
+*/
+func ParseGeoNames(r io.Reader) ([]Place, error) {
+	places := make([]Place, 0)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		columns := strings.Split(scanner.Text(), "\t")
+		if len(columns) < geonamesColumns {
+			return nil, fmt.Errorf("line %d has %d columns, expected %d", line, len(columns), geonamesColumns)
+		}
+
+		if columns[6] != "P" && columns[6] != "A" {
+			continue
+		}
+
+		id, err := strconv.Atoi(columns[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid geoname id: %w", line, err)
+		}
+
+		lat, err := strconv.ParseFloat(columns[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid latitude: %w", line, err)
+		}
+
+		lon, err := strconv.ParseFloat(columns[5], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid longitude: %w", line, err)
+		}
+
+		population, _ := strconv.ParseInt(columns[14], 10, 64)
+
+		places = append(places, Place{
+			GeonameID:      id,
+			Name:           columns[1],
+			AsciiName:      columns[2],
+			AlternateNames: columns[3],
+			Latitude:       lat,
+			Longitude:      lon,
+			FeatureClass:   columns[6],
+			FeatureCode:    columns[7],
+			CountryCode:    columns[8],
+			Admin1Code:     columns[10],
+			Admin2Code:     columns[11],
+			Population:     population,
+		})
+	}
+
+	return places, scanner.Err()
+}
+
+/*
+*
+Loads GeoNames country extracts into the gazetteer of db. The places of every country in a file replace the
+places of that country that are already loaded.
+*/
+func LoadFile(db *gorm.DB, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	places, err := ParseGeoNames(f)
+	if err != nil {
+		return 0, fmt.Errorf("failed parsing %s: %w", path, err)
+	}
+
+	countries := make(map[string]bool)
+	for _, p := range places {
+		countries[p.CountryCode] = true
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for c := range countries {
+			if res := tx.Where("country_code = ?", c).Delete(&Place{}); res.Error != nil {
+				return res.Error
+			}
+		}
+
+		if len(places) == 0 {
+			return nil
+		}
+
+		return tx.CreateInBatches(&places, 1000).Error
+	})
+
+	return len(places), err
+}
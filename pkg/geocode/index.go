@@ -0,0 +1,237 @@
+package geocode
+
+import (
+	"gorm.io/gorm"
+	"missing-persons-scrapper/pkg/normalize"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// the name matched one place of the country, or the county given in the text narrowed it down to one
+	confidenceExact = 1.0
+	// the name matched more than one place, the one with the largest population was taken
+	confidenceAmbiguous = 0.7
+	// the name only matched a place in another country
+	confidenceOtherCountry = 0.5
+)
+
+/*
+*
+A place name from the scrapped data resolved to a place of the gazetteer. Confidence is 0 if nothing
+was found.
+*/
+type Location struct {
+	Query        string  `json:"query"`
+	GeonameID    int     `json:"geoname_id"`
+	Name         string  `json:"name"`
+	Municipality string  `json:"municipality"`
+	County       string  `json:"county"`
+	CountryCode  string  `json:"country_code"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	Confidence   float64 `json:"confidence"`
+}
+
+func (l Location) Found() bool {
+	return l.Confidence > 0
+}
+
+// In memory lookup of places by their folded names and alternate names.
+type Index struct {
+	byName         map[string][]Place
+	counties       map[string]Place
+	municipalities map[string]Place
+}
+
+// prefixes of place names that are not part of the name ("općina Sveta Nedelja", "jud. Cluj", "mun. Iași")
+var placePrefixes = regexp.MustCompile(`^(grad|opcina|mjesto|naselje|zupanija|municipiul|mun\.?|orasul|oras|or\.?|comuna|com\.?|satul|sat|judetul|jud\.?|sectorul|sector)\s+`)
+
+var countySuffix = regexp.MustCompile(`\s+(zupanija|county)$`)
+
+func NewIndex(places []Place) *Index {
+	idx := &Index{
+		byName:         make(map[string][]Place),
+		counties:       make(map[string]Place),
+		municipalities: make(map[string]Place),
+	}
+
+	for _, p := range places {
+		switch p.FeatureCode {
+		case "ADM1":
+			idx.counties[p.CountryCode+"."+p.Admin1Code] = p
+		case "ADM2":
+			idx.municipalities[p.CountryCode+"."+p.Admin1Code+"."+p.Admin2Code] = p
+		}
+
+		names := map[string]bool{foldPlace(p.Name): true, foldPlace(p.AsciiName): true}
+		for _, alt := range strings.Split(p.AlternateNames, ",") {
+			if alt != "" {
+				names[foldPlace(alt)] = true
+			}
+		}
+
+		for n := range names {
+			if n != "" {
+				idx.byName[n] = append(idx.byName[n], p)
+			}
+		}
+	}
+
+	return idx
+}
+
+// Loads the index from the gazetteer table of db.
+func LoadIndex(db *gorm.DB) (*Index, error) {
+	var places []Place
+	if res := db.Find(&places); res.Error != nil {
+		return nil, res.Error
+	}
+
+	return NewIndex(places), nil
+}
+
+/*
+*
+Resolves a place name in free text ("Zagreb", "mun. Cluj-Napoca, jud. Cluj", "Sveta Nedelja, Zagrebačka
+županija") to a place. Every comma separated part is tried in order, the first part that is a known place
+wins. Places of the country (ISO code, "HR", "RO") are preferred, and a county mentioned in the text
+narrows down places with the same name.
+*/
+func (idx *Index) Geocode(text, country string) Location {
+	loc := Location{Query: text}
+	country = strings.ToUpper(country)
+
+	parts := strings.Split(text, ",")
+	county := idx.countyHint(parts)
+
+	for _, part := range parts {
+		name := foldPlace(part)
+		if name == "" {
+			continue
+		}
+
+		candidates := idx.byName[name]
+		if len(candidates) == 0 {
+			continue
+		}
+
+		place, confidence := idx.choose(candidates, country, county)
+		return idx.location(loc, place, confidence)
+	}
+
+	return loc
+}
+
+func (idx *Index) choose(candidates []Place, country, county string) (Place, float64) {
+	inCountry := make([]Place, 0)
+	for _, c := range candidates {
+		if c.CountryCode == country {
+			inCountry = append(inCountry, c)
+		}
+	}
+
+	confidence := confidenceAmbiguous
+	if len(inCountry) == 0 {
+		inCountry = candidates
+		confidence = confidenceOtherCountry
+	}
+
+	if county != "" {
+		inCounty := make([]Place, 0)
+		for _, c := range inCountry {
+			if countyName(idx.counties[c.CountryCode+"."+c.Admin1Code].Name) == county {
+				inCounty = append(inCounty, c)
+			}
+		}
+
+		if len(inCounty) > 0 {
+			inCountry = inCounty
+		}
+	}
+
+	// populated places before administrative areas of the same name, then the largest one
+	sort.SliceStable(inCountry, func(i, j int) bool {
+		if inCountry[i].FeatureClass != inCountry[j].FeatureClass {
+			return inCountry[i].FeatureClass == "P"
+		}
+
+		return inCountry[i].Population > inCountry[j].Population
+	})
+
+	if confidence == confidenceAmbiguous && distinctPlaces(inCountry) == 1 {
+		confidence = confidenceExact
+	}
+
+	return inCountry[0], confidence
+}
+
+func (idx *Index) location(loc Location, p Place, confidence float64) Location {
+	loc.GeonameID = p.GeonameID
+	loc.Name = p.Name
+	loc.CountryCode = p.CountryCode
+	loc.Latitude = p.Latitude
+	loc.Longitude = p.Longitude
+	loc.Confidence = confidence
+	loc.County = idx.counties[p.CountryCode+"."+p.Admin1Code].Name
+	loc.Municipality = idx.municipalities[p.CountryCode+"."+p.Admin1Code+"."+p.Admin2Code].Name
+
+	if p.FeatureCode == "ADM2" {
+		loc.Municipality = p.Name
+	}
+
+	return loc
+}
+
+// the folded name of a county that is mentioned in the text ("jud. Cluj", "Zagrebačka županija")
+func (idx *Index) countyHint(parts []string) string {
+	for _, part := range parts {
+		folded := normalize.Fold(strings.TrimSpace(part))
+		if !strings.HasPrefix(folded, "jud") && !countySuffix.MatchString(folded) {
+			continue
+		}
+
+		name := countyName(part)
+		for _, c := range idx.counties {
+			if n := countyName(c.Name); n == name {
+				return n
+			}
+		}
+	}
+
+	return ""
+}
+
+/*
+*
+Populated places and the administrative area of the same name (the city of Split and the Split municipality)
+are one place. Different populated places of the same name are not.
+*/
+func distinctPlaces(places []Place) int {
+	count := 0
+	for _, p := range places {
+		if p.FeatureClass == "P" {
+			count++
+		}
+	}
+
+	return max(1, count)
+}
+
+func countyName(name string) string {
+	return countySuffix.ReplaceAllString(foldPlace(name), "")
+}
+
+func foldPlace(name string) string {
+	folded := strings.TrimSpace(normalize.Fold(name))
+	for {
+		trimmed := placePrefixes.ReplaceAllString(folded, "")
+		if trimmed == folded {
+			break
+		}
+		folded = trimmed
+	}
+
+	return strings.Join(strings.Fields(strings.Trim(folded, ".")), " ")
+}
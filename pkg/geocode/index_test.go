@@ -0,0 +1,66 @@
+package geocode
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// a few rows in the format of the GeoNames country extracts
+var testGeoNames = strings.Join([]string{
+	"3337532\tGrad Zagreb\tGrad Zagreb\tZagreb City\t45.83333\t16\tA\tADM1\tHR\t\t21\t\t\t\t790017\t\t\t\tEurope/Zagreb\t2021-01-01",
+	"3337531\tZagrebačka\tZagrebacka\tZagrebacka zupanija\t45.83333\t16.16667\tA\tADM1\tHR\t\t01\t\t\t\t309696\t\t\t\tEurope/Zagreb\t2021-01-01",
+	"3186886\tZagreb\tZagreb\tZagabria,Zagrabia\t45.81444\t15.97798\tP\tPPLC\tHR\t\t21\t\t\t\t698966\t\t\t\tEurope/Zagreb\t2021-01-01",
+	"3190033\tSveta Nedelja\tSveta Nedelja\t\t45.79194\t15.7775\tP\tPPL\tHR\t\t01\t\t\t\t1825\t\t\t\tEurope/Zagreb\t2021-01-01",
+	"3190034\tSveta Nedelja\tSveta Nedelja\t\t45.15\t13.98\tP\tPPL\tHR\t\t18\t\t\t\t300\t\t\t\tEurope/Zagreb\t2021-01-01",
+	"681290\tJudeţul Cluj\tJudetul Cluj\tCluj\t46.75\t23.5\tA\tADM1\tRO\t\t13\t\t\t\t691106\t\t\t\tEurope/Bucharest\t2021-01-01",
+	"681292\tMunicipiul Cluj-Napoca\tMunicipiul Cluj-Napoca\t\t46.76667\t23.6\tA\tADM2\tRO\t\t13\t54975\t\t\t324576\t\t\t\tEurope/Bucharest\t2021-01-01",
+	"681293\tCluj-Napoca\tCluj-Napoca\tKolozsvar,Klausenburg\t46.76667\t23.6\tP\tPPLA\tRO\t\t13\t54975\t\t\t316748\t\t\t\tEurope/Bucharest\t2021-01-01",
+	"6077243\tMontréal\tMontreal\t\t45.50884\t-73.58781\tP\tPPLA2\tCA\t\t10\t\t\t\t1600000\t\t\t\tAmerica/Toronto\t2021-01-01",
+	"100\tSava\tSava\t\t45.5\t16.5\tH\tSTM\tHR\t\t00\t\t\t\t0\t\t\t\tEurope/Zagreb\t2021-01-01",
+}, "\n")
+
+func testIndex(t *testing.T) *Index {
+	places, err := ParseGeoNames(strings.NewReader(testGeoNames))
+	assert.Nil(t, err)
+	// the river is not a place
+	assert.Len(t, places, 9)
+
+	return NewIndex(places)
+}
+
+func TestGeocode(t *testing.T) {
+	idx := testIndex(t)
+
+	loc := idx.Geocode("Zagreb", "hr")
+	assert.Equal(t, 3186886, loc.GeonameID)
+	assert.Equal(t, "Grad Zagreb", loc.County)
+	assert.Equal(t, confidenceExact, loc.Confidence)
+
+	loc = idx.Geocode("mun. Cluj-Napoca, jud. Cluj", "ro")
+	assert.Equal(t, "Cluj-Napoca", loc.Name)
+	assert.Equal(t, "Municipiul Cluj-Napoca", loc.Municipality)
+	assert.Equal(t, "Judeţul Cluj", loc.County)
+	assert.Equal(t, confidenceExact, loc.Confidence)
+
+	loc = idx.Geocode("Kolozsvár", "ro")
+	assert.Equal(t, "Cluj-Napoca", loc.Name)
+
+	// two places with the same name, the county decides
+	loc = idx.Geocode("Sveta Nedelja, Zagrebačka županija", "hr")
+	assert.Equal(t, 3190033, loc.GeonameID)
+	assert.Equal(t, confidenceExact, loc.Confidence)
+
+	// without the county the larger one is taken
+	loc = idx.Geocode("Sveta Nedelja", "hr")
+	assert.Equal(t, 3190033, loc.GeonameID)
+	assert.Equal(t, confidenceAmbiguous, loc.Confidence)
+
+	loc = idx.Geocode("Montreal, Kanada", "hr")
+	assert.Equal(t, "CA", loc.CountryCode)
+	assert.Equal(t, confidenceOtherCountry, loc.Confidence)
+
+	loc = idx.Geocode("nepoznato", "hr")
+	assert.False(t, loc.Found())
+	assert.Equal(t, "nepoznato", loc.Query)
+}
@@ -0,0 +1,30 @@
+package geocode
+
+const Gazetteer_Table = "gazetteer_places"
+
+/*
+*
+A place from a GeoNames country extract (https://download.geonames.org/export/dump/, HR.zip and RO.zip).
+Only populated places (feature class P) and administrative areas (feature class A) are kept.
+
+Counties are the ADM1 areas (županije, județe) and municipalities the ADM2 areas (gradovi and općine,
+municipii, orașe and comune) of the same country.
+*/
+type Place struct {
+	GeonameID      int     `gorm:"column:geoname_id;primaryKey;autoIncrement:false"`
+	Name           string  `gorm:"column:name"`
+	AsciiName      string  `gorm:"column:ascii_name"`
+	AlternateNames string  `gorm:"column:alternate_names"`
+	Latitude       float64 `gorm:"column:latitude"`
+	Longitude      float64 `gorm:"column:longitude"`
+	FeatureClass   string  `gorm:"column:feature_class"`
+	FeatureCode    string  `gorm:"column:feature_code"`
+	CountryCode    string  `gorm:"column:country_code;index"`
+	Admin1Code     string  `gorm:"column:admin1_code"`
+	Admin2Code     string  `gorm:"column:admin2_code"`
+	Population     int64   `gorm:"column:population"`
+}
+
+func (Place) TableName() string {
+	return Gazetteer_Table
+}
@@ -1,24 +1,31 @@
 package htmlParser
 
 import (
-	"io"
+	"fmt"
 	"missing-persons-scrapper/pkg/httpClient"
+	"net/http"
 )
 
-func GetBody(url string) ([]byte, error) {
-	response, err := httpClient.SendRequest(url)
-
+// Returns the body of the page, a page that is not 200 OK is an error.
+func GetBody(fetcher httpClient.Fetcher, url string) ([]byte, error) {
+	page, err := GetPage(fetcher, url)
 	if err != nil {
 		return nil, err
 	}
 
-	defer response.Body.Close()
-
-	body, err := io.ReadAll(response.Body)
+	return page.Body, nil
+}
 
+// Returns the page, a page that is not 200 OK is an error. Page.Unchanged tells whether it changed since the last run.
+func GetPage(fetcher httpClient.Fetcher, url string) (httpClient.Page, error) {
+	page, err := fetcher.Fetch(url)
 	if err != nil {
-		return nil, err
+		return httpClient.Page{}, err
+	}
+
+	if page.StatusCode != http.StatusOK {
+		return httpClient.Page{}, fmt.Errorf("request returned %d for %s", page.StatusCode, url)
 	}
 
-	return body, nil
+	return page, nil
 }
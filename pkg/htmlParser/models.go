@@ -17,6 +17,10 @@ type RawPerson struct {
 	Hair     string
 	EyeColor string
 	Weight   string
+	Build    string
+
+	DistinguishingMarks string
+	Clothing            string
 
 	// Date of disappearance
 	DOD string
@@ -24,8 +28,121 @@ type RawPerson struct {
 	POD string
 
 	Description string
+
+	// Confidence (0-1) of the fields that were extracted from free text instead of a labeled value
+	Confidence map[string]float64
 }
 
 func NewRawPerson() RawPerson {
-	return RawPerson{}
+	return RawPerson{Confidence: make(map[string]float64)}
+}
+
+// Set assigns the value to the field with the given name. Unknown fields are ignored.
+func (p *RawPerson) Set(field, value string) {
+	switch field {
+	case "Name":
+		p.Name = value
+	case "LastName":
+		p.LastName = value
+	case "MaidenName":
+		p.MaidenName = value
+	case "Gender":
+		p.Gender = value
+	case "DOB":
+		p.DOB = value
+	case "POB":
+		p.POB = value
+	case "Citizenship":
+		p.Citizenship = value
+	case "PrimaryAddress":
+		p.PrimaryAddress = value
+	case "SecondaryAddress":
+		p.SecondaryAddress = value
+	case "Country":
+		p.Country = value
+	case "ImageURL":
+		p.ImageURL = value
+	case "Height":
+		p.Height = value
+	case "Hair":
+		p.Hair = value
+	case "EyeColor":
+		p.EyeColor = value
+	case "Weight":
+		p.Weight = value
+	case "Build":
+		p.Build = value
+	case "DistinguishingMarks":
+		p.DistinguishingMarks = value
+	case "Clothing":
+		p.Clothing = value
+	case "DOD":
+		p.DOD = value
+	case "POD":
+		p.POD = value
+	case "Description":
+		p.Description = value
+	}
+}
+
+// Get returns the value of the field with the given name. Unknown fields are empty.
+func (p RawPerson) Get(field string) string {
+	switch field {
+	case "Name":
+		return p.Name
+	case "LastName":
+		return p.LastName
+	case "MaidenName":
+		return p.MaidenName
+	case "Gender":
+		return p.Gender
+	case "DOB":
+		return p.DOB
+	case "POB":
+		return p.POB
+	case "Citizenship":
+		return p.Citizenship
+	case "PrimaryAddress":
+		return p.PrimaryAddress
+	case "SecondaryAddress":
+		return p.SecondaryAddress
+	case "Country":
+		return p.Country
+	case "ImageURL":
+		return p.ImageURL
+	case "Height":
+		return p.Height
+	case "Hair":
+		return p.Hair
+	case "EyeColor":
+		return p.EyeColor
+	case "Weight":
+		return p.Weight
+	case "Build":
+		return p.Build
+	case "DistinguishingMarks":
+		return p.DistinguishingMarks
+	case "Clothing":
+		return p.Clothing
+	case "DOD":
+		return p.DOD
+	case "POD":
+		return p.POD
+	case "Description":
+		return p.Description
+	}
+
+	return ""
+}
+
+func (p RawPerson) FullName() string {
+	if p.Name == "" {
+		return p.LastName
+	}
+
+	if p.LastName == "" {
+		return p.Name
+	}
+
+	return p.Name + " " + p.LastName
 }
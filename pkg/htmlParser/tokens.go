@@ -0,0 +1,80 @@
+package htmlParser
+
+import (
+	"golang.org/x/net/html/atom"
+	"strings"
+)
+
+/*
+*
+Maps the scrapped tokens onto a RawPerson. Both sources render their details as a list of
+label/value pairs ("Ime:", "Marko"), so every token that matches a known label takes the
+token right after it as its value. Labels are matched case insensitive and without the trailing colon.
+
+labels maps the source label to the RawPerson field name (see RawPerson.Set).
+*/
+func NewRawPersonFromTokens(tokens []string, labels map[string]string) RawPerson {
+	person := NewRawPerson()
+
+	walkTokens(tokens, labels, func(field, value string) {
+		person.Set(field, value)
+	}, nil)
+
+	return person
+}
+
+/*
+*
+Returns the tokens that are neither a label nor the value of a label, like free text paragraphs.
+Tokens that are only the name of an element (the first child of the element was another element)
+and blank tokens are skipped.
+*/
+func UnlabeledTokens(tokens []string, labels map[string]string) []string {
+	unlabeled := make([]string, 0)
+
+	walkTokens(tokens, labels, nil, func(token string) {
+		token = strings.TrimSpace(token)
+		if token == "" || atom.Lookup([]byte(token)) != 0 {
+			return
+		}
+
+		unlabeled = append(unlabeled, token)
+	})
+
+	return unlabeled
+}
+
+func walkTokens(tokens []string, labels map[string]string, onValue func(field, value string), onOther func(token string)) {
+	normalized := make(map[string]string, len(labels))
+	for label, field := range labels {
+		normalized[normalizeLabel(label)] = field
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		field, ok := normalized[normalizeLabel(tokens[i])]
+		if !ok {
+			if onOther != nil {
+				onOther(tokens[i])
+			}
+			continue
+		}
+
+		if i == len(tokens)-1 {
+			continue
+		}
+
+		value := strings.TrimSpace(tokens[i+1])
+		if _, isLabel := normalized[normalizeLabel(value)]; isLabel {
+			continue
+		}
+
+		if onValue != nil {
+			onValue(field, value)
+		}
+		i++
+	}
+}
+
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(label), ":"))
+}
@@ -0,0 +1,133 @@
+package httpClient
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// The last 200 response of a URL.
+type CacheEntry struct {
+	URL          string      `json:"url"`
+	ETag         string      `json:"etag"`
+	LastModified string      `json:"last_modified"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+	// sha256 of the body, compared for sites that send no validators
+	BodyHash  string    `json:"body_hash"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+/*
+*
+Keeps the last response of every URL on disk, one JSON file per URL named by the sha256 of the URL. The
+cache only stores what the sites sent, so it can be deleted at any time, the next run fetches everything.
+*/
+type Cache struct {
+	root string
+}
+
+func NewCache(root string) (*Cache, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Cache{root: root}, nil
+}
+
+func (c *Cache) path(url string) string {
+	key := fmt.Sprintf("%x", sha256.Sum256([]byte(url)))
+	return filepath.Join(c.root, key[0:2], key+".json")
+}
+
+// Returns the entry of the URL, ok is false if the URL was not fetched before.
+func (c *Cache) Get(url string) (CacheEntry, bool, error) {
+	data, err := os.ReadFile(c.path(url))
+	if errors.Is(err, fs.ErrNotExist) {
+		return CacheEntry{}, false, nil
+	} else if err != nil {
+		return CacheEntry{}, false, err
+	}
+
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		// a damaged entry is fetched again
+		return CacheEntry{}, false, nil
+	}
+
+	return entry, entry.URL == url, nil
+}
+
+func (c *Cache) Put(entry CacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	path := c.path(entry.URL)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// an entry is either complete or the previous one, a run that is stopped never leaves half an entry
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+func bodyHash(body []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(body))
+}
+
+var ErrNotCached = errors.New("the page is not in the cache")
+
+type replay struct {
+	cache *Cache
+}
+
+/*
+*
+Returns a Fetcher that reads the pages from the cache instead of fetching them, so pages can be parsed again
+without requesting them from the sites. A URL that is not in the cache fails with ErrNotCached.
+*/
+func NewReplayFetcher(cache *Cache) Fetcher {
+	return &replay{cache: cache}
+}
+
+func (r *replay) Fetch(url string) (Page, error) {
+	entry, ok, err := r.cache.Get(url)
+	if err != nil {
+		return Page{}, err
+	}
+
+	if !ok {
+		return Page{}, fmt.Errorf("%w: %s", ErrNotCached, url)
+	}
+
+	return Page{URL: url, StatusCode: http.StatusOK, Header: entry.Header, Body: entry.Body}, nil
+}
@@ -0,0 +1,75 @@
+package httpClient
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCachedFetcher(t *testing.T) {
+	body := "<html>v1</html>"
+	conditional := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/etag", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("<html>etag</html>"))
+	})
+	// a site without validators
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cache, err := NewCache(t.TempDir())
+	assert.Nil(t, err)
+	fetcher := NewCachedFetcher(ClientParams{Transport: http.DefaultTransport}, cache)
+
+	page, err := fetcher.Fetch(server.URL + "/etag")
+	assert.Nil(t, err)
+	assert.False(t, page.Unchanged)
+
+	page, err = fetcher.Fetch(server.URL + "/etag")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, conditional)
+	assert.True(t, page.Unchanged)
+	assert.Equal(t, http.StatusOK, page.StatusCode)
+	assert.Equal(t, "<html>etag</html>", string(page.Body))
+
+	page, err = fetcher.Fetch(server.URL + "/plain")
+	assert.Nil(t, err)
+	assert.False(t, page.Unchanged)
+
+	page, err = fetcher.Fetch(server.URL + "/plain")
+	assert.Nil(t, err)
+	assert.True(t, page.Unchanged)
+
+	body = "<html>v2</html>"
+	page, err = fetcher.Fetch(server.URL + "/plain")
+	assert.Nil(t, err)
+	assert.False(t, page.Unchanged)
+	assert.Equal(t, body, string(page.Body))
+}
+
+func TestReplayFetcher(t *testing.T) {
+	cache, err := NewCache(t.TempDir())
+	assert.Nil(t, err)
+	assert.Nil(t, cache.Put(CacheEntry{URL: "https://nestali.gov.hr/a", Body: []byte("<html></html>")}))
+
+	fetcher := NewReplayFetcher(cache)
+	page, err := fetcher.Fetch("https://nestali.gov.hr/a")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, page.StatusCode)
+	assert.Equal(t, "<html></html>", string(page.Body))
+
+	_, err = fetcher.Fetch("https://nestali.gov.hr/b")
+	assert.ErrorIs(t, err, ErrNotCached)
+}
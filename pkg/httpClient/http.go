@@ -2,46 +2,161 @@ package httpClient
 
 import (
 	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"time"
 )
 
-func SendRequest(url string) (*http.Response, error) {
-	var backoffSchedule = []time.Duration{
-		1 * time.Second,
-		3 * time.Second,
-		10 * time.Second,
+// A fetched page, the body is read completely so the response does not have to be closed.
+type Page struct {
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// the page is the same as the last time it was fetched, the site answered 304 Not Modified or sent the
+	// same body. Only a Fetcher with a cache knows, the body is the cached one.
+	Unchanged bool
+}
+
+/*
+*
+Fetches pages for the scrapers. A Fetcher only fails if the page could not be fetched at all, a page with
+a non 200 status code is returned with its status code.
+*/
+type Fetcher interface {
+	Fetch(url string) (Page, error)
+}
+
+type client struct {
+	client *http.Client
+	// the waits between the tries of a request, a request is tried once more than there are waits
+	backoff []time.Duration
+	sleep   func(time.Duration)
+	// nil if responses are not cached
+	cache *Cache
+}
+
+/*
+*
+Returns a Fetcher that tries a request three times, it waits 1 and then 3 seconds before trying again. The official sites have
+certificates that do not verify, so certificates are not verified if params has no Transport.
+*/
+func NewFetcher(params ClientParams) Fetcher {
+	if params.Transport == nil {
+		params.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
 	}
 
-	var res *http.Response
-	var err error
+	return &client{
+		client: NewClient(params),
+		backoff: []time.Duration{
+			1 * time.Second,
+			3 * time.Second,
+		},
+		sleep: time.Sleep,
+	}
+}
 
-	for _, backoff := range backoffSchedule {
-		request, rErr := NewRequest(Request{
-			Headers: nil,
-			Url:     url,
-			Method:  "GET",
-			Body:    nil,
-		})
+/*
+*
+Returns a Fetcher like NewFetcher that keeps the responses in cache. A URL that was fetched before is
+requested with If-None-Match and If-Modified-Since, a 304 Not Modified response or a body that is the same as
+the cached one returns the cached page with Unchanged set.
+*/
+func NewCachedFetcher(params ClientParams, cache *Cache) Fetcher {
+	c := NewFetcher(params).(*client)
+	c.cache = cache
 
-		if rErr != nil {
-			return nil, rErr
+	return c
+}
+
+func (c *client) Fetch(url string) (Page, error) {
+	for try := 0; ; try++ {
+		page, err := c.fetch(url)
+		if err == nil {
+			return page, nil
+		}
+
+		// no wait after the last try
+		if try == len(c.backoff) {
+			return page, err
 		}
 
-		res, err = Make(request, NewClient(ClientParams{
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-			},
-		}))
+		c.sleep(c.backoff[try])
+	}
+}
 
-		if err != nil {
-			time.Sleep(backoff)
+func (c *client) fetch(url string) (Page, error) {
+	var cached CacheEntry
+	hasCached := false
+	headers := make(map[string]string)
+
+	if c.cache != nil {
+		var err error
+		if cached, hasCached, err = c.cache.Get(url); err != nil {
+			return Page{}, err
+		}
 
-			continue
+		if hasCached && cached.ETag != "" {
+			headers["If-None-Match"] = cached.ETag
 		}
 
-		return res, err
+		if hasCached && cached.LastModified != "" {
+			headers["If-Modified-Since"] = cached.LastModified
+		}
+	}
+
+	request, err := NewRequest(Request{
+		Headers: headers,
+		Url:     url,
+		Method:  "GET",
+		Body:    nil,
+	})
+
+	if err != nil {
+		return Page{}, err
+	}
+
+	res, err := Make(request, c.client)
+	if err != nil {
+		return Page{}, err
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return Page{}, err
+	}
+
+	if hasCached && res.StatusCode == http.StatusNotModified {
+		return Page{URL: url, StatusCode: http.StatusOK, Header: cached.Header, Body: cached.Body, Unchanged: true}, nil
+	}
+
+	page := Page{URL: url, StatusCode: res.StatusCode, Header: res.Header, Body: body}
+	if c.cache == nil || res.StatusCode != http.StatusOK {
+		return page, nil
+	}
+
+	hash := bodyHash(body)
+	page.Unchanged = hasCached && hash == cached.BodyHash
+
+	// the page was fetched, a cache that cannot be written only costs a full download next time
+	err = c.cache.Put(CacheEntry{
+		URL:          url,
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		Header:       res.Header,
+		Body:         body,
+		BodyHash:     hash,
+		FetchedAt:    time.Now(),
+	})
+	if err != nil {
+		log.Println(fmt.Errorf("failed caching %s: %w", url, err))
 	}
 
-	return res, err
+	return page, nil
 }
@@ -0,0 +1,32 @@
+package httpClient
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type failingTransport struct {
+	requests int
+}
+
+func (f *failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	f.requests++
+	return nil, errors.New("connection refused")
+}
+
+func TestFetchRetries(t *testing.T) {
+	transport := &failingTransport{}
+	c := NewFetcher(ClientParams{Transport: transport}).(*client)
+
+	slept := make([]time.Duration, 0)
+	c.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	_, err := c.Fetch("https://nestali.gov.hr")
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, transport.requests)
+	// no wait after the last try
+	assert.Equal(t, []time.Duration{time.Second, 3 * time.Second}, slept)
+}
@@ -0,0 +1,53 @@
+package imagefile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var ErrNotImage = errors.New("not an image")
+
+type Format struct {
+	Name      string
+	MimeType  string
+	Extension string
+}
+
+var (
+	JPEG = Format{Name: "jpeg", MimeType: "image/jpeg", Extension: "jpg"}
+	PNG  = Format{Name: "png", MimeType: "image/png", Extension: "png"}
+	GIF  = Format{Name: "gif", MimeType: "image/gif", Extension: "gif"}
+	WebP = Format{Name: "webp", MimeType: "image/webp", Extension: "webp"}
+)
+
+/*
+*
+Detects the format by the magic bytes at the start of data, the url and the Content-Type of an image are
+not trusted. Returns ErrNotImage if data is not one of the supported formats, an HTML error page for example.
+*/
+func Detect(data []byte) (Format, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return JPEG, nil
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return PNG, nil
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return GIF, nil
+	case len(data) >= 12 && bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return WebP, nil
+	}
+
+	return Format{}, ErrNotImage
+}
+
+// Returns the format with the name, the names are the values of IMAGE_FORMAT.
+func FormatByName(name string) (Format, error) {
+	for _, f := range []Format{JPEG, PNG} {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+
+	return Format{}, fmt.Errorf("images cannot be encoded as %s, use jpeg or png", name)
+}
@@ -0,0 +1,182 @@
+package imagefile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 10), G: uint8(y * 10), B: 100, A: 255})
+		}
+	}
+
+	return img
+}
+
+// a JPEG with an EXIF segment that has the orientation and a camera make
+func testJPEG(t *testing.T, orientation uint16) []byte {
+	buff := &bytes.Buffer{}
+	assert.Nil(t, jpeg.Encode(buff, testImage(4, 2), nil))
+	data := buff.Bytes()
+
+	tiff := &bytes.Buffer{}
+	tiff.WriteString("MM")
+	binary.Write(tiff, binary.BigEndian, uint16(42))
+	binary.Write(tiff, binary.BigEndian, uint32(8))
+	binary.Write(tiff, binary.BigEndian, uint16(1))
+	// the orientation entry: tag, SHORT, count 1, value
+	binary.Write(tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(tiff, binary.BigEndian, uint32(1))
+	binary.Write(tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(tiff, binary.BigEndian, uint32(0))
+	tiff.WriteString("Canon EOS")
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+
+	out := append([]byte{}, data[0:2]...)
+	out = append(out, app1...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func TestDetect(t *testing.T) {
+	_, err := Detect([]byte("<!DOCTYPE html><html><body>Not found</body></html>"))
+	assert.ErrorIs(t, err, ErrNotImage)
+
+	format, err := Detect(testJPEG(t, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, JPEG, format)
+}
+
+func TestNormalizeJPEG(t *testing.T) {
+	file, err := Normalize(testJPEG(t, 1), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, JPEG, file.Format)
+	assert.Equal(t, 4, file.Width)
+	assert.Equal(t, 2, file.Height)
+	assert.False(t, bytes.Contains(file.Data, []byte("Exif")))
+	assert.False(t, bytes.Contains(file.Data, []byte("Canon")))
+
+	// rotated by 90 degrees, the rotation is applied before the orientation is stripped
+	file, err = Normalize(testJPEG(t, 6), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, file.Width)
+	assert.Equal(t, 4, file.Height)
+	assert.False(t, bytes.Contains(file.Data, []byte("Canon")))
+
+	// a download that was cut off
+	data := testJPEG(t, 1)
+	_, err = Normalize(data[:len(data)/2], nil)
+	assert.ErrorIs(t, err, ErrNotImage)
+}
+
+func TestNormalizePNG(t *testing.T) {
+	buff := &bytes.Buffer{}
+	assert.Nil(t, png.Encode(buff, testImage(3, 3)))
+	data := buff.Bytes()
+
+	text := []byte("Author\x00Someone")
+	chunk := make([]byte, 8)
+	binary.BigEndian.PutUint32(chunk, uint32(len(text)))
+	copy(chunk[4:], "tEXt")
+	chunk = append(chunk, text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	// before IEND
+	end := len(data) - 12
+	withText := append(append(append([]byte{}, data[:end]...), chunk...), data[end:]...)
+
+	file, err := Normalize(withText, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, data, file.Data)
+
+	file, err = Normalize(withText, &JPEG)
+	assert.Nil(t, err)
+	assert.Equal(t, JPEG, file.Format)
+	format, err := Detect(file.Data)
+	assert.Nil(t, err)
+	assert.Equal(t, JPEG, format)
+}
+
+func TestThumbnail(t *testing.T) {
+	buff := &bytes.Buffer{}
+	assert.Nil(t, png.Encode(buff, testImage(40, 20)))
+
+	file, err := Thumbnail(buff.Bytes(), 10)
+	assert.Nil(t, err)
+	assert.Equal(t, JPEG, file.Format)
+	assert.Equal(t, 10, file.Width)
+	assert.Equal(t, 5, file.Height)
+
+	decoded, err := jpeg.Decode(bytes.NewReader(file.Data))
+	assert.Nil(t, err)
+	assert.Equal(t, 10, decoded.Bounds().Dx())
+
+	// smaller images are not scaled up
+	file, err = Thumbnail(buff.Bytes(), 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 40, file.Width)
+	assert.Equal(t, 20, file.Height)
+}
+
+func TestPerceptualHash(t *testing.T) {
+	original := image.NewGray(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			// brighter to the right in the top half and to the left in the bottom half
+			if y < 32 {
+				original.SetGray(x, y, color.Gray{Y: uint8(x * 4)})
+			} else {
+				original.SetGray(x, y, color.Gray{Y: uint8(255 - x*4)})
+			}
+		}
+	}
+	hash := PerceptualHash(original)
+
+	// the same photo re-encoded and resized
+	buff := &bytes.Buffer{}
+	assert.Nil(t, jpeg.Encode(buff, original, &jpeg.Options{Quality: 50}))
+	small, err := Thumbnail(buff.Bytes(), 32)
+	assert.Nil(t, err)
+	resized, err := HashData(small.Data)
+	assert.Nil(t, err)
+	assert.LessOrEqual(t, Distance(hash, resized), 6)
+
+	// a different photo
+	flipped := image.NewRGBA(original.Bounds())
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			flipped.Set(63-x, y, original.At(x, y))
+		}
+	}
+	assert.Greater(t, Distance(hash, PerceptualHash(flipped)), 20)
+
+	_, err = HashData([]byte("not an image"))
+	assert.ErrorIs(t, err, ErrNotImage)
+}
+
+func TestHashIndex(t *testing.T) {
+	index := NewHashIndex()
+	index.Add(0b0000, 1)
+	index.Add(0b0001, 2)
+	index.Add(0b0111, 3)
+	index.Add(0b0000, 4)
+	index.Add(^uint64(0), 5)
+	assert.Equal(t, 5, index.Len())
+
+	assert.Equal(t, []HashMatch{{1, 0}, {4, 0}, {2, 1}}, index.Search(0, 1))
+	assert.Equal(t, []HashMatch{{3, 0}, {2, 2}, {1, 3}, {4, 3}}, index.Search(0b0111, 3))
+	assert.Empty(t, index.Search(0b1111<<32, 2))
+}
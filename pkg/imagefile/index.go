@@ -0,0 +1,101 @@
+package imagefile
+
+import "sort"
+
+/*
+*
+Finds perceptual hashes by Hamming distance without comparing every hash. It is a BK-tree: every child of
+a node is kept under its distance to the node, so by the triangle inequality a search only visits the
+children whose distance is within maxDistance of the distance of the searched hash to the node.
+*/
+type HashIndex struct {
+	root *hashNode
+	size int
+}
+
+type hashNode struct {
+	hash     uint64
+	ids      []int
+	children map[int]*hashNode
+}
+
+// A hash found in the index, the id it was added with and its distance to the searched hash.
+type HashMatch struct {
+	ID       int
+	Distance int
+}
+
+func NewHashIndex() *HashIndex {
+	return &HashIndex{}
+}
+
+func (x *HashIndex) Len() int {
+	return x.size
+}
+
+// Adds the hash with an id, usually the position of what it belongs to. Equal hashes share a node.
+func (x *HashIndex) Add(hash uint64, id int) {
+	x.size++
+	if x.root == nil {
+		x.root = &hashNode{hash: hash, ids: []int{id}}
+		return
+	}
+
+	node := x.root
+	for {
+		d := Distance(hash, node.hash)
+		if d == 0 {
+			node.ids = append(node.ids, id)
+			return
+		}
+
+		child, ok := node.children[d]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[int]*hashNode)
+			}
+
+			node.children[d] = &hashNode{hash: hash, ids: []int{id}}
+			return
+		}
+
+		node = child
+	}
+}
+
+// Returns every id whose hash differs in at most maxDistance bits, the closest first.
+func (x *HashIndex) Search(hash uint64, maxDistance int) []HashMatch {
+	matches := make([]HashMatch, 0)
+	if x.root == nil {
+		return matches
+	}
+
+	stack := []*hashNode{x.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		d := Distance(hash, node.hash)
+		if d <= maxDistance {
+			for _, id := range node.ids {
+				matches = append(matches, HashMatch{ID: id, Distance: d})
+			}
+		}
+
+		for childDistance, child := range node.children {
+			if childDistance >= d-maxDistance && childDistance <= d+maxDistance {
+				stack = append(stack, child)
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+
+		return matches[i].ID < matches[j].ID
+	})
+
+	return matches
+}
@@ -0,0 +1,88 @@
+package imagefile
+
+import (
+	"bytes"
+	"fmt"
+	_ "golang.org/x/image/webp"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// A validated image without metadata.
+type File struct {
+	Format Format
+	Width  int
+	Height int
+	Data   []byte
+	// perceptual hash of the image, see PerceptualHash
+	PHash uint64
+}
+
+/*
+*
+Validates data by its magic bytes and by decoding it, and strips the metadata (EXIF, XMP, IPTC, comments
+and text chunks) that can contain where and when a photo was taken or who edited it.
+
+If canonical is not empty every image is re-encoded to that format. A JPEG that is rotated by its EXIF
+orientation is always re-encoded, with the rotation applied, because the orientation is stripped.
+*/
+func Normalize(data []byte, canonical *Format) (File, error) {
+	format, err := Detect(data)
+	if err != nil {
+		return File{}, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return File{}, fmt.Errorf("%w: cannot decode %s: %s", ErrNotImage, format.Name, err.Error())
+	}
+
+	bounds := img.Bounds()
+	file := File{Format: format, Width: bounds.Dx(), Height: bounds.Dy()}
+
+	orientation := 1
+	if format == JPEG {
+		orientation = jpegOrientation(data)
+	}
+
+	if orientation > 1 && orientation <= 8 {
+		img = orient(img, orientation)
+		if canonical == nil {
+			canonical = &JPEG
+		}
+	}
+
+	// hashed as it is shown, after the rotation
+	file.PHash = PerceptualHash(img)
+
+	if canonical != nil {
+		file.Format = *canonical
+		file.Width = img.Bounds().Dx()
+		file.Height = img.Bounds().Dy()
+		file.Data, err = encode(img, *canonical)
+
+		return file, err
+	}
+
+	file.Data, err = strip(data, format)
+
+	return file, err
+}
+
+func encode(img image.Image, format Format) ([]byte, error) {
+	buff := &bytes.Buffer{}
+
+	var err error
+	switch format {
+	case JPEG:
+		err = jpeg.Encode(buff, img, &jpeg.Options{Quality: 90})
+	case PNG:
+		err = png.Encode(buff, img)
+	default:
+		err = fmt.Errorf("images cannot be encoded as %s", format.Name)
+	}
+
+	return buff.Bytes(), err
+}
@@ -0,0 +1,47 @@
+package imagefile
+
+import (
+	"bytes"
+	"fmt"
+	"golang.org/x/image/draw"
+	"image"
+	"math/bits"
+)
+
+/*
+*
+Returns the difference hash (dHash) of the image. The image is scaled down to 9x8 gray pixels and every bit
+tells whether a pixel is brighter than its right neighbour, so the same photo re-encoded, resized or slightly
+recolored gets the same hash or a hash that differs in a few bits.
+*/
+func PerceptualHash(img image.Image) uint64 {
+	small := image.NewGray(image.Rect(0, 0, 9, 8))
+	draw.BiLinear.Scale(small, small.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if small.GrayAt(x, y).Y > small.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash
+}
+
+// Decodes data and returns its perceptual hash.
+func HashData(data []byte) (uint64, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrNotImage, err.Error())
+	}
+
+	return PerceptualHash(img), nil
+}
+
+// The number of different bits of two perceptual hashes, 0 for the same photo and 64 for the most different.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
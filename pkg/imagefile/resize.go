@@ -0,0 +1,50 @@
+package imagefile
+
+import (
+	"bytes"
+	"fmt"
+	"golang.org/x/image/draw"
+	"image"
+)
+
+/*
+*
+Scales the image down so its longer side is at most maxSize pixels and encodes it as JPEG. An image that is
+smaller already is only re-encoded, it is never scaled up.
+*/
+func Thumbnail(data []byte, maxSize int) (File, error) {
+	if maxSize < 1 {
+		return File{}, fmt.Errorf("invalid thumbnail size %d", maxSize)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return File{}, fmt.Errorf("%w: %s", ErrNotImage, err.Error())
+	}
+
+	bounds := src.Bounds()
+	w, h := fit(bounds.Dx(), bounds.Dy(), maxSize)
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	encoded, err := encode(dst, JPEG)
+	if err != nil {
+		return File{}, err
+	}
+
+	return File{Format: JPEG, Width: w, Height: h, Data: encoded}, nil
+}
+
+// the dimensions of a w x h image scaled to fit in maxSize x maxSize, keeping the aspect ratio
+func fit(w, h, maxSize int) (int, int) {
+	if w <= maxSize && h <= maxSize {
+		return w, h
+	}
+
+	if w >= h {
+		return maxSize, max(1, h*maxSize/w)
+	}
+
+	return max(1, w*maxSize/h), maxSize
+}
@@ -0,0 +1,226 @@
+package imagefile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+)
+
+// Removes the metadata from data, the image itself is copied byte for byte.
+func strip(data []byte, format Format) ([]byte, error) {
+	switch format {
+	case JPEG:
+		return stripJPEG(data)
+	case PNG:
+		return stripPNG(data)
+	case WebP:
+		return stripWebP(data)
+	}
+
+	// GIF has no EXIF
+	return data, nil
+}
+
+/*
+*
+Drops the APP1 (EXIF and XMP), APP13 (IPTC) and COM segments. APP0 (JFIF), APP2 (the ICC colour profile)
+and APP14 (Adobe colour transform) are needed to show the colours right and are kept.
+*/
+func stripJPEG(data []byte) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[0:2])
+
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return nil, fmt.Errorf("%w: invalid jpeg segment", ErrNotImage)
+		}
+
+		marker := data[i+1]
+		// the start of scan is followed by the image data up to the end
+		if marker == 0xDA {
+			out.Write(data[i:])
+			return out.Bytes(), nil
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, fmt.Errorf("%w: truncated jpeg segment", ErrNotImage)
+		}
+
+		if marker != 0xE1 && marker != 0xED && marker != 0xFE {
+			out.Write(data[i:end])
+		}
+
+		i = end
+	}
+
+	return nil, fmt.Errorf("%w: jpeg without image data", ErrNotImage)
+}
+
+// chunks with text, the time of the last change and EXIF
+var pngMetadata = map[string]bool{"tEXt": true, "zTXt": true, "iTXt": true, "tIME": true, "eXIf": true}
+
+func stripPNG(data []byte) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[0:8])
+
+	i := 8
+	for i+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[i : i+4]))
+		chunk := string(data[i+4 : i+8])
+		// length, type, data and crc
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, fmt.Errorf("%w: truncated png chunk", ErrNotImage)
+		}
+
+		if !pngMetadata[chunk] {
+			out.Write(data[i:end])
+		}
+
+		i = end
+		if chunk == "IEND" {
+			return out.Bytes(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: png without end", ErrNotImage)
+}
+
+// Drops the EXIF and XMP chunks and clears their flags in the VP8X chunk.
+func stripWebP(data []byte) ([]byte, error) {
+	chunks := bytes.NewBuffer(make([]byte, 0, len(data)))
+
+	i := 12
+	for i+8 <= len(data) {
+		chunk := string(data[i : i+4])
+		length := int(binary.LittleEndian.Uint32(data[i+4 : i+8]))
+		// chunks are padded to an even size
+		end := i + 8 + length + length%2
+		if end > len(data) {
+			return nil, fmt.Errorf("%w: truncated webp chunk", ErrNotImage)
+		}
+
+		switch chunk {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			vp8x := append([]byte{}, data[i:end]...)
+			// the XMP (0x04) and EXIF (0x08) flags
+			vp8x[8] &^= 0x04 | 0x08
+			chunks.Write(vp8x)
+		default:
+			chunks.Write(data[i:end])
+		}
+
+		i = end
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, 12+chunks.Len()))
+	out.WriteString("RIFF")
+	binary.Write(out, binary.LittleEndian, uint32(4+chunks.Len()))
+	out.WriteString("WEBP")
+	out.Write(chunks.Bytes())
+
+	return out.Bytes(), nil
+}
+
+// Returns the EXIF orientation of a JPEG, 1 (not rotated) if it has none.
+func jpegOrientation(data []byte) int {
+	i := 2
+	for i+4 <= len(data) && data[i] == 0xFF && data[i+1] != 0xDA {
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + length
+		if end > len(data) {
+			return 1
+		}
+
+		segment := data[i+4 : end]
+		if data[i+1] == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+
+		i = end
+	}
+
+	return 1
+}
+
+// reads the orientation tag (0x0112) of the first IFD of a TIFF header
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for e := 0; e < entries; e++ {
+		entry := ifd + 2 + e*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+
+	return 1
+}
+
+/*
+*
+Applies an EXIF orientation (2 to 8) to the image. Orientations 5 to 8 swap the width and the height.
+*/
+func orient(img image.Image, orientation int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+
+			dst.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,88 @@
+package imagestore
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Keeps every image in its own file, in directories named by the first two and the next two characters of the key.
+type filesystem struct {
+	root string
+}
+
+func NewFilesystem(root string) (Store, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &filesystem{root: root}, nil
+}
+
+func (f *filesystem) path(key string) string {
+	return filepath.Join(f.root, key[0:2], key[2:4], key)
+}
+
+func (f *filesystem) Put(data []byte, _ string) (string, error) {
+	key := Key(data)
+	path := f.path(key)
+
+	if _, err := os.Stat(path); err == nil {
+		return key, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+
+	// a file is either complete or not there, a reader never sees a partially written image
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	return key, nil
+}
+
+func (f *filesystem) Get(key string) ([]byte, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(f.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	return data, err
+}
+
+func (f *filesystem) Exists(key string) (bool, error) {
+	if err := validKey(key); err != nil {
+		return false, err
+	}
+
+	_, err := os.Stat(f.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
@@ -0,0 +1,74 @@
+package imagestore
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var ErrNotFound = errors.New("image not found")
+
+const (
+	KindFilesystem = "filesystem"
+	KindS3         = "s3"
+)
+
+/*
+*
+Stores the image files outside the database. The store is content addressable, an image is stored under
+the hex encoded sha256 of its content, so an image that is downloaded again is not stored twice.
+*/
+type Store interface {
+	// stores data under its key and returns the key
+	Put(data []byte, contentType string) (string, error)
+	// returns ErrNotFound if there is no image with the key
+	Get(key string) ([]byte, error)
+	Exists(key string) (bool, error)
+}
+
+func Key(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+/*
+*
+IMAGE_STORE selects the store, "filesystem" (the default) or "s3". The filesystem store keeps the images in
+IMAGE_STORE_PATH (./images by default). The S3 store is configured with S3_ENDPOINT, S3_REGION, S3_BUCKET,
+S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY and works with any S3 compatible storage like MinIO.
+*/
+func FromEnv() (Store, error) {
+	switch kind := os.Getenv("IMAGE_STORE"); kind {
+	case "", KindFilesystem:
+		path := os.Getenv("IMAGE_STORE_PATH")
+		if path == "" {
+			path = "images"
+		}
+
+		return NewFilesystem(path)
+	case KindS3:
+		return NewS3(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown image store %s", kind)
+	}
+}
+
+func validKey(key string) error {
+	if len(key) != sha256.Size*2 {
+		return fmt.Errorf("invalid image key %s", key)
+	}
+
+	for _, c := range key {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return fmt.Errorf("invalid image key %s", key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package matching
+
+import (
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"missing-persons-scrapper/pkg/persons"
+	"time"
+)
+
+// name parts shorter than this are too common to find candidates by
+const minNameTokenLength = 3
+
+/*
+*
+Scores pairs of persons of every country of db against each other and writes the pairs that are likely the
+same person to the links table for review. Only pairs that share a name part, the date of birth, the
+image or a part of the perceptual hash of the image are scored. Returns the number of candidate pairs.
+*/
+func Run(db *gorm.DB) (int, error) {
+	all, err := persons.Find(db, persons.Filter{})
+	if err != nil {
+		return 0, err
+	}
+
+	candidates := latestPerItem(all)
+	links := make([]Link, 0)
+
+	for _, pair := range candidatePairs(candidates) {
+		a, b := candidates[pair[0]], candidates[pair[1]]
+
+		s, reasons := score(a, b)
+		if s < candidateThreshold {
+			continue
+		}
+
+		r, _ := json.Marshal(reasons)
+		links = append(links, NewLink(a.person.Country, a.person.ItemID, b.person.Country, b.person.ItemID, s, r))
+	}
+
+	if len(links) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	for i := range links {
+		links[i].CreatedAt = now
+		links[i].UpdatedAt = now
+	}
+
+	res := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "country_a"}, {Name: "item_id_a"}, {Name: "country_b"}, {Name: "item_id_b"}},
+		DoUpdates: clause.AssignmentColumns([]string{"score", "reasons", "updated_at"}),
+	}).CreateInBatches(&links, 500)
+
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed saving person links: %w", res.Error)
+	}
+
+	return len(links), nil
+}
+
+/*
+*
+A person that changed on the website has more than one raw row with the same website id, only the
+newest one is matched.
+*/
+func latestPerItem(all []persons.Person) []candidate {
+	latest := make(map[string]persons.Person)
+	for _, p := range all {
+		key := PersonKey(p.Country, p.ItemID)
+		if current, ok := latest[key]; !ok || p.RawID > current.RawID {
+			latest[key] = p
+		}
+	}
+
+	candidates := make([]candidate, 0, len(latest))
+	for _, p := range latest {
+		candidates = append(candidates, newCandidate(p))
+	}
+
+	return candidates
+}
+
+// index pairs of candidates that share at least one blocking key
+func candidatePairs(candidates []candidate) [][2]int {
+	blocks := make(map[string][]int)
+	for i, c := range candidates {
+		for _, key := range blockingKeys(c) {
+			blocks[key] = append(blocks[key], i)
+		}
+	}
+
+	seen := make(map[[2]int]bool)
+	pairs := make([][2]int, 0)
+	for _, block := range blocks {
+		for x := 0; x < len(block); x++ {
+			for y := x + 1; y < len(block); y++ {
+				pair := [2]int{min(block[x], block[y]), max(block[x], block[y])}
+				if seen[pair] {
+					continue
+				}
+
+				seen[pair] = true
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+
+	return pairs
+}
+
+func blockingKeys(c candidate) []string {
+	keys := make([]string, 0)
+	for _, n := range c.names {
+		if len([]rune(n)) >= minNameTokenLength {
+			keys = append(keys, "name:"+n)
+		}
+	}
+
+	if c.dob.Valid {
+		keys = append(keys, "dob:"+c.dob.Value)
+	}
+
+	if c.person.ImageHash != "" {
+		keys = append(keys, "image:"+c.person.ImageHash)
+	}
+
+	// the bits that differ are in at most similarImageDistance of the blocks, so similar hashes have a block in common
+	if c.person.ImagePHash != nil {
+		hash := uint64(*c.person.ImagePHash)
+		for i := 0; i < phashBlocks; i++ {
+			from, to := 64*i/phashBlocks, 64*(i+1)/phashBlocks
+			keys = append(keys, fmt.Sprintf("phash:%d:%x", i, (hash>>from)&(1<<(to-from)-1)))
+		}
+	}
+
+	return keys
+}
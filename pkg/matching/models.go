@@ -0,0 +1,65 @@
+package matching
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+const Person_Links_Table = "person_links"
+
+const (
+	StatusCandidate = "candidate"
+	StatusConfirmed = "confirmed"
+	StatusRejected  = "rejected"
+)
+
+/*
+*
+A possible link between two persons that are most likely the same person. Persons are identified by the
+country and the website id, A is always the smaller of the two so every pair is stored once.
+
+Candidates are reviewed by a person and either confirmed or rejected. Matching again only updates the
+score, never the review.
+*/
+type Link struct {
+	ID         int            `json:"id"`
+	CountryA   string         `gorm:"column:country_a;uniqueIndex:idx_person_links_pair" json:"country_a"`
+	ItemIDA    string         `gorm:"column:item_id_a;uniqueIndex:idx_person_links_pair" json:"item_id_a"`
+	CountryB   string         `gorm:"column:country_b;uniqueIndex:idx_person_links_pair" json:"country_b"`
+	ItemIDB    string         `gorm:"column:item_id_b;uniqueIndex:idx_person_links_pair" json:"item_id_b"`
+	Score      float64        `gorm:"column:score" json:"score"`
+	Reasons    datatypes.JSON `gorm:"type:jsonb" json:"reasons"`
+	Status     string         `gorm:"column:status;index" json:"status"`
+	CreatedAt  time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	ReviewedAt *time.Time     `gorm:"column:reviewed_at" json:"reviewed_at"`
+}
+
+func (Link) TableName() string {
+	return Person_Links_Table
+}
+
+func NewLink(countryA, itemIDA, countryB, itemIDB string, score float64, reasons []byte) Link {
+	if countryB < countryA || (countryB == countryA && itemIDB < itemIDA) {
+		countryA, itemIDA, countryB, itemIDB = countryB, itemIDB, countryA, itemIDA
+	}
+
+	return Link{
+		CountryA: countryA,
+		ItemIDA:  itemIDA,
+		CountryB: countryB,
+		ItemIDB:  itemIDB,
+		Score:    score,
+		Reasons:  reasons,
+		Status:   StatusCandidate,
+	}
+}
+
+// Other returns the country and website id of the person on the other side of the link.
+func (l Link) Other(country, itemID string) (string, string) {
+	if l.CountryA == country && l.ItemIDA == itemID {
+		return l.CountryB, l.ItemIDB
+	}
+
+	return l.CountryA, l.ItemIDA
+}
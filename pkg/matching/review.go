@@ -0,0 +1,68 @@
+package matching
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Links of db with the given status (every link if empty), best scores first.
+func List(db *gorm.DB, status string) ([]Link, error) {
+	var links []Link
+
+	tx := db.Order("score DESC").Order("id")
+	if status != "" {
+		tx = tx.Where("status = ?", status)
+	}
+
+	res := tx.Find(&links)
+	return links, res.Error
+}
+
+func Confirm(db *gorm.DB, id int) (Link, error) {
+	return review(db, id, StatusConfirmed)
+}
+
+func Reject(db *gorm.DB, id int) (Link, error) {
+	return review(db, id, StatusRejected)
+}
+
+func review(db *gorm.DB, id int, status string) (Link, error) {
+	var link Link
+	if res := db.First(&link, id); res.Error != nil {
+		return link, res.Error
+	}
+
+	now := time.Now()
+	link.Status = status
+	link.ReviewedAt = &now
+
+	if res := db.Model(&link).Select("status", "reviewed_at").Updates(&link); res.Error != nil {
+		return link, fmt.Errorf("failed updating link %d: %w", id, res.Error)
+	}
+
+	return link, nil
+}
+
+/*
+*
+Confirmed links of every person, keyed by "country:item_id". Both persons of a link have it.
+*/
+func ConfirmedLinks(db *gorm.DB) (map[string][]Link, error) {
+	links, err := List(db, StatusConfirmed)
+	if err != nil {
+		return nil, err
+	}
+
+	byPerson := make(map[string][]Link)
+	for _, l := range links {
+		byPerson[PersonKey(l.CountryA, l.ItemIDA)] = append(byPerson[PersonKey(l.CountryA, l.ItemIDA)], l)
+		byPerson[PersonKey(l.CountryB, l.ItemIDB)] = append(byPerson[PersonKey(l.CountryB, l.ItemIDB)], l)
+	}
+
+	return byPerson, nil
+}
+
+func PersonKey(country, itemID string) string {
+	return country + ":" + itemID
+}
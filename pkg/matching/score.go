@@ -0,0 +1,100 @@
+package matching
+
+import (
+	"missing-persons-scrapper/pkg/imagefile"
+	"missing-persons-scrapper/pkg/normalize"
+	"missing-persons-scrapper/pkg/persons"
+)
+
+// pairs that score at least this are written for review
+const candidateThreshold = 0.75
+
+const (
+	nameWeight       = 0.5
+	sameDOBDay       = 0.3
+	sameDOBYear      = 0.15
+	differentDOB     = -0.3
+	sameGender       = 0.05
+	differentGender  = -0.3
+	sameImageContent = 0.4
+	similarImage     = 0.3
+)
+
+// images whose perceptual hashes differ in at most this many bits are most likely the same photo
+const similarImageDistance = 6
+
+// the perceptual hash is split into this many blocks for blocking, one more than the bits similar hashes differ in
+const phashBlocks = similarImageDistance + 1
+
+// How much every compared value added to the score of a pair.
+type Reasons struct {
+	Name   float64 `json:"name"`
+	DOB    float64 `json:"dob"`
+	Gender float64 `json:"gender"`
+	Image  float64 `json:"image"`
+}
+
+func (r Reasons) total() float64 {
+	return min(1, max(0, r.Name+r.DOB+r.Gender+r.Image))
+}
+
+// the values of a person that are compared, prepared once per person
+type candidate struct {
+	person persons.Person
+	names  []string
+	dob    normalize.Date
+}
+
+func newCandidate(p persons.Person) candidate {
+	return candidate{
+		person: p,
+		names:  nameTokens(p.Name + " " + p.LastName),
+		dob:    p.Normalized().DOB,
+	}
+}
+
+func score(a, b candidate) (float64, Reasons) {
+	r := Reasons{
+		Name: nameWeight * nameSimilarity(a.names, b.names),
+	}
+
+	if a.dob.Valid && b.dob.Valid {
+		ta, _ := a.dob.Time()
+		tb, _ := b.dob.Time()
+
+		switch {
+		case a.dob.Precision == normalize.PrecisionDay && b.dob.Precision == normalize.PrecisionDay && ta.Equal(tb):
+			r.DOB = sameDOBDay
+		case ta.Year() == tb.Year():
+			r.DOB = sameDOBYear
+		case abs(ta.Year()-tb.Year()) > 1:
+			r.DOB = differentDOB
+		}
+	}
+
+	if a.person.Gender != "" && b.person.Gender != "" {
+		if a.person.Gender == b.person.Gender {
+			r.Gender = sameGender
+		} else {
+			r.Gender = differentGender
+		}
+	}
+
+	if a.person.ImageHash != "" && a.person.ImageHash == b.person.ImageHash {
+		r.Image = sameImageContent
+	} else if a.person.ImagePHash != nil && b.person.ImagePHash != nil &&
+		imagefile.Distance(uint64(*a.person.ImagePHash), uint64(*b.person.ImagePHash)) <= similarImageDistance {
+		// the same photo re-encoded or resized by the site
+		r.Image = similarImage
+	}
+
+	return r.total(), r
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+
+	return i
+}
@@ -0,0 +1,100 @@
+package matching
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"missing-persons-scrapper/pkg/normalize"
+	"missing-persons-scrapper/pkg/persons"
+	"missing-persons-scrapper/pkg/storage"
+	"testing"
+)
+
+func testCandidate(country, itemID, name, lastName, dob, gender, imageHash string) candidate {
+	n := normalize.Person{Name: name, LastName: lastName, DOB: normalize.ParseDate(dob)}
+	data, _ := json.Marshal(n)
+
+	return newCandidate(persons.Person{
+		Country:   country,
+		ItemID:    itemID,
+		Name:      name,
+		LastName:  lastName,
+		Gender:    gender,
+		ImageHash: imageHash,
+		Data:      data,
+	})
+}
+
+func TestJaroWinkler(t *testing.T) {
+	assert.Equal(t, 1.0, jaroWinkler("marko", "marko"))
+	assert.Equal(t, 0.0, jaroWinkler("", "marko"))
+	assert.InDelta(t, 0.961, jaroWinkler("martha", "marhta"), 0.001)
+	assert.InDelta(t, 0.840, jaroWinkler("dwayne", "duane"), 0.001)
+}
+
+func TestScore(t *testing.T) {
+	a := testCandidate("hr", "1", "Ana", "Popescu", "12.03.1987.", "female", "")
+	b := testCandidate("ro", "2", "Popescu", "Ana", "12 martie 1987", "female", "")
+	s, reasons := score(a, b)
+	assert.GreaterOrEqual(t, s, candidateThreshold)
+	assert.Equal(t, sameDOBDay, reasons.DOB)
+
+	// same name but a different person
+	c := testCandidate("ro", "3", "Ana", "Popescu", "1960.", "female", "")
+	s, _ = score(a, c)
+	assert.Less(t, s, candidateThreshold)
+
+	// the same photo makes up for a missing date of birth
+	d := testCandidate("hr", "4", "Ana", "Popesku", "", "", "abc")
+	e := testCandidate("ro", "5", "Ana", "Popescu", "", "", "abc")
+	s, reasons = score(d, e)
+	assert.GreaterOrEqual(t, s, candidateThreshold)
+	assert.Equal(t, sameImageContent, reasons.Image)
+}
+
+func TestSimilarImage(t *testing.T) {
+	a := testCandidate("hr", "1", "Ana", "Popesku", "", "", "abc")
+	b := testCandidate("ro", "2", "Ana", "Popescu", "", "", "def")
+	a.person.ImagePHash = storage.PHash(0xf0f0f0f0f0f0f0f0)
+	// re-encoded, 3 bits differ
+	b.person.ImagePHash = storage.PHash(0xf0f0f0f0f0f0f0f7)
+
+	s, reasons := score(a, b)
+	assert.GreaterOrEqual(t, s, candidateThreshold)
+	assert.Equal(t, similarImage, reasons.Image)
+
+	// found only by the perceptual hash
+	c := testCandidate("hr", "3", "Al", "", "", "", "")
+	d := testCandidate("ro", "4", "Bo", "", "", "", "")
+	c.person.ImagePHash, d.person.ImagePHash = a.person.ImagePHash, b.person.ImagePHash
+	assert.Equal(t, [][2]int{{0, 1}}, candidatePairs([]candidate{c, d}))
+
+	// 6 bits differ, in every quarter of the hash
+	d.person.ImagePHash = storage.PHash(0xf0f0f0f0f0f0f0f0 ^ (1 | 1<<1 | 1<<16 | 1<<33 | 1<<48 | 1<<63))
+	_, reasons = score(c, d)
+	assert.Equal(t, similarImage, reasons.Image)
+	assert.Equal(t, [][2]int{{0, 1}}, candidatePairs([]candidate{c, d}))
+
+	b.person.ImagePHash = storage.PHash(0x0f0f0f0f0f0f0f0f)
+	_, reasons = score(a, b)
+	assert.Zero(t, reasons.Image)
+}
+
+func TestCandidatePairs(t *testing.T) {
+	candidates := []candidate{
+		testCandidate("hr", "1", "Ana", "Popescu", "", "", ""),
+		testCandidate("ro", "2", "Ion", "Popescu", "", "", ""),
+		testCandidate("ro", "3", "Marko", "Marić", "", "", ""),
+	}
+
+	assert.Equal(t, [][2]int{{0, 1}}, candidatePairs(candidates))
+}
+
+func TestNewLinkOrdersPair(t *testing.T) {
+	link := NewLink("ro", "2", "hr", "1", 0.9, nil)
+	assert.Equal(t, "hr", link.CountryA)
+	assert.Equal(t, "1", link.ItemIDA)
+
+	country, itemID := link.Other("hr", "1")
+	assert.Equal(t, "ro", country)
+	assert.Equal(t, "2", itemID)
+}
@@ -0,0 +1,103 @@
+package matching
+
+import (
+	"missing-persons-scrapper/pkg/normalize"
+	"sort"
+	"strings"
+)
+
+/*
+*
+Jaro-Winkler similarity of two strings, 1 for equal strings and 0 for strings with nothing in common.
+*/
+func jaroWinkler(a, b string) float64 {
+	s1, s2 := []rune(a), []rune(b)
+	if len(s1) == 0 || len(s2) == 0 {
+		return 0
+	}
+
+	if a == b {
+		return 1
+	}
+
+	window := max(len(s1), len(s2))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	matched1 := make([]bool, len(s1))
+	matched2 := make([]bool, len(s2))
+
+	matches := 0
+	for i := range s1 {
+		from, to := max(0, i-window), min(len(s2), i+window+1)
+		for j := from; j < to; j++ {
+			if matched2[j] || s1[i] != s2[j] {
+				continue
+			}
+
+			matched1[i], matched2[j] = true, true
+			matches++
+			break
+		}
+	}
+
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	j := 0
+	for i := range s1 {
+		if !matched1[i] {
+			continue
+		}
+
+		for !matched2[j] {
+			j++
+		}
+
+		if s1[i] != s2[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s1)) + m/float64(len(s2)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(s1), len(s2)) && s1[prefix] == s2[prefix] {
+		prefix++
+	}
+
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// lowercase name parts without diacritics
+func nameTokens(name string) []string {
+	return strings.FieldsFunc(normalize.Fold(name), func(r rune) bool {
+		return r == ' ' || r == '-' || r == ',' || r == '.'
+	})
+}
+
+/*
+*
+Compares the names in the order they are written and sorted, because the sources do not agree on
+which of the names comes first.
+*/
+func nameSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	sortedA := append([]string{}, a...)
+	sortedB := append([]string{}, b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+
+	return max(
+		jaroWinkler(strings.Join(a, " "), strings.Join(b, " ")),
+		jaroWinkler(strings.Join(sortedA, " "), strings.Join(sortedB, " ")),
+	)
+}
@@ -0,0 +1,309 @@
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"io/fs"
+	"missing-persons-scrapper/pkg/storage"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+*
+Migrations are SQL files in sql/<dialect>, named <version>_<name>.up.sql and <version>_<name>.down.sql.
+Versions are applied in order and every applied version is recorded in the schema_migrations table.
+*/
+//go:embed sql
+var files embed.FS
+
+const Migrations_Table = "schema_migrations"
+
+var ErrOutdated = errors.New("the database schema is outdated, run migrate up")
+var ErrNewer = errors.New("the database schema is newer than this program")
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// An applied migration, a row of schema_migrations.
+type Applied struct {
+	Version   int       `gorm:"column:version;primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"column:name"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (Applied) TableName() string {
+	return Migrations_Table
+}
+
+// A migration and whether it is applied, as printed by migrate status.
+type State struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+/*
+*
+Returns the migrations of the database's dialect ordered by version. Every version must have both the up
+and the down file.
+*/
+func Load(db *gorm.DB) ([]Migration, error) {
+	migrations, err := loadDir(path.Join("sql", db.Dialector.Name()))
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %s: %w", db.Dialector.Name(), err)
+	}
+
+	return migrations, nil
+}
+
+// the migrations of the files in dir, subdirectories are skipped
+func loadDir(dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		name := e.Name()
+		direction := ""
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		version, migrationName, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+
+		v, err := strconv.Atoi(version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", name, err)
+		}
+
+		b, err := fs.ReadFile(files, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[v]
+		if !ok {
+			m = &Migration{Version: v, Name: migrationName}
+			byVersion[v] = m
+		}
+
+		if direction == "up" {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Applies every migration that is not applied yet. Returns the number of applied migrations.
+func Up(db *gorm.DB) (int, error) {
+	migrations, applied, err := load(db)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := exec(tx, m.Up); err != nil {
+				return err
+			}
+
+			return tx.Create(&Applied{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+
+		if err != nil {
+			return count, fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+
+		count++
+	}
+
+	// the tables of every country get the versions of the table templates they are missing
+	tables, err := upTrackedTables(db)
+
+	return count + tables, err
+}
+
+// Reverts the last steps applied migrations. Returns the number of reverted migrations.
+func Down(db *gorm.DB, steps int) (int, error) {
+	migrations, applied, err := load(db)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := exec(tx, m.Down); err != nil {
+				return err
+			}
+
+			return tx.Where("version = ?", m.Version).Delete(&Applied{}).Error
+		})
+
+		if err != nil {
+			return count, fmt.Errorf("reverting migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+func Status(db *gorm.DB) ([]State, error) {
+	migrations, applied, err := load(db)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]State, 0, len(migrations))
+	for _, m := range migrations {
+		state := State{Migration: m}
+		if a, ok := applied[m.Version]; ok {
+			appliedAt := a.AppliedAt
+			state.AppliedAt = &appliedAt
+		}
+
+		states = append(states, state)
+	}
+
+	return states, nil
+}
+
+/*
+*
+Returns ErrOutdated if a migration is not applied and ErrNewer if the database has a migration this program
+does not know about, in both cases the program should not read or write the database.
+*/
+func Check(db *gorm.DB) error {
+	migrations, applied, err := load(db)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[int]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+		if _, ok := applied[m.Version]; !ok {
+			return fmt.Errorf("%w: %04d_%s is not applied", ErrOutdated, m.Version, m.Name)
+		}
+	}
+
+	for version, a := range applied {
+		if !known[version] {
+			return fmt.Errorf("%w: %04d_%s is unknown", ErrNewer, version, a.Name)
+		}
+	}
+
+	return checkTables(db)
+}
+
+func load(db *gorm.DB) ([]Migration, map[int]Applied, error) {
+	migrations, err := Load(db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := db.Exec(createTable(db)).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var rows []Applied
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	applied := make(map[int]Applied, len(rows))
+	for _, a := range rows {
+		applied[a.Version] = a
+	}
+
+	return migrations, applied, nil
+}
+
+func createTable(db *gorm.DB) string {
+	timestamp := "timestamptz"
+	if db.Dialector.Name() == storage.DriverSQLite {
+		timestamp = "datetime"
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version integer PRIMARY KEY, name text NOT NULL, applied_at %s NOT NULL)", Migrations_Table, timestamp)
+}
+
+// Prepared statements can hold only one statement, so the file is executed statement by statement.
+func exec(tx *gorm.DB, sql string) error {
+	for _, statement := range statements(sql) {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Splits a file on semicolons, comment lines are dropped. The migrations do not use semicolons in strings.
+func statements(sql string) []string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(sql, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	result := make([]string, 0)
+	for _, s := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if s = strings.TrimSpace(s); s != "" {
+			result = append(result, s)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,138 @@
+package migrations
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"missing-persons-scrapper/pkg/storage"
+	"testing"
+	"time"
+)
+
+func testDB(t *testing.T) *gorm.DB {
+	db, err := storage.Open(storage.Config{Driver: storage.DriverSQLite, Path: ":memory:"})
+	assert.Nil(t, err)
+
+	return db
+}
+
+func TestUpAndDown(t *testing.T) {
+	db := testDB(t)
+
+	assert.True(t, errors.Is(Check(db), ErrOutdated))
+
+	migrations, err := Load(db)
+	assert.Nil(t, err)
+
+	count, err := Up(db)
+	assert.Nil(t, err)
+	assert.Equal(t, len(migrations), count)
+	assert.Nil(t, Check(db))
+
+	count, err = Up(db)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+
+	count, err = Down(db, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	assert.True(t, errors.Is(Check(db), ErrOutdated))
+
+	states, err := Status(db)
+	assert.Nil(t, err)
+	assert.NotNil(t, states[0].AppliedAt)
+	assert.Nil(t, states[len(states)-1].AppliedAt)
+
+	count, err = Down(db, len(migrations))
+	assert.Nil(t, err)
+	assert.Equal(t, len(migrations)-1, count)
+	assert.False(t, db.Migrator().HasTable("croatia_scrapped"))
+}
+
+func TestUniqueIdentifier(t *testing.T) {
+	db := testDB(t)
+
+	// a database created before the unique index, with a duplicated person
+	count, err := Up(db)
+	assert.Nil(t, err)
+	_, err = Down(db, count-1)
+	assert.Nil(t, err)
+
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, db.Exec("INSERT INTO croatia_scrapped (item_id, unique_identifier, first_seen, last_seen) VALUES (?, ?, ?, ?)", "1", "abc", now, now).Error)
+	}
+	assert.Nil(t, db.Exec("INSERT INTO croatia_images (item_id, extension) VALUES (2, 'jpg')").Error)
+
+	_, err = Up(db)
+	assert.Nil(t, err)
+
+	var rows int64
+	db.Table("croatia_scrapped").Count(&rows)
+	assert.Equal(t, int64(1), rows)
+	db.Table("croatia_images").Count(&rows)
+	assert.Equal(t, int64(0), rows)
+
+	err = db.Exec("INSERT INTO croatia_scrapped (item_id, unique_identifier) VALUES (?, ?)", "2", "abc").Error
+	assert.NotNil(t, err)
+}
+
+func TestNewerSchema(t *testing.T) {
+	db := testDB(t)
+
+	_, err := Up(db)
+	assert.Nil(t, err)
+	assert.Nil(t, db.Create(&Applied{Version: 9999, Name: "future", AppliedAt: time.Now()}).Error)
+
+	assert.True(t, errors.Is(Check(db), ErrNewer))
+}
+
+func columns(t *testing.T, db *gorm.DB, table string) map[string]string {
+	types, err := db.Migrator().ColumnTypes(table)
+	assert.Nil(t, err)
+
+	result := make(map[string]string, len(types))
+	for _, c := range types {
+		result[c.Name()] = c.DatabaseTypeName()
+	}
+
+	return result
+}
+
+func TestTables(t *testing.T) {
+	db := testDB(t)
+
+	_, err := Up(db)
+	assert.Nil(t, err)
+
+	// a defined country whose tables migrate up did not create yet
+	tables := storage.Tables{Raw: "slovenia_scrapped", Images: "slovenia_images"}
+	assert.True(t, errors.Is(CheckTables(db, tables), ErrOutdated))
+	assert.False(t, db.Migrator().HasTable(tables.Raw))
+
+	count, err := UpTables(db, tables)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	assert.Nil(t, Check(db))
+	assert.Nil(t, CheckTables(db, tables))
+
+	// the tables of a defined country are the same as the ones the migrations left for croatia
+	assert.Equal(t, columns(t, db, "croatia_scrapped"), columns(t, db, tables.Raw))
+	assert.Equal(t, columns(t, db, "croatia_images"), columns(t, db, tables.Images))
+	assert.True(t, db.Migrator().HasIndex(tables.Raw, "slovenia_scrapped_unique_identifier_key"))
+	assert.True(t, db.Migrator().HasIndex(tables.Images, "slovenia_images_item_id_hash_key"))
+
+	states, err := TablesStatus(db)
+	assert.Nil(t, err)
+	assert.Len(t, states, 3)
+
+	assert.Nil(t, db.Create(&TablesApplied{Raw: tables.Raw, Images: tables.Images, Version: 9999, Name: "future", AppliedAt: time.Now()}).Error)
+	assert.True(t, errors.Is(Check(db), ErrNewer))
+
+	count, err = DownTables(db, tables.Raw, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	assert.False(t, db.Migrator().HasTable(tables.Raw))
+	assert.Nil(t, db.Where("raw_table = ?", tables.Raw).Delete(&TablesApplied{}).Error)
+	assert.Nil(t, Check(db))
+}
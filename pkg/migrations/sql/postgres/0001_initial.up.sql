@@ -0,0 +1,137 @@
+-- The schema as it was created by gorm AutoMigrate. Databases that were created by AutoMigrate before
+-- migrations existed already have these tables, the columns added after the first release are added to them.
+
+CREATE TABLE IF NOT EXISTS croatia_scrapped (
+    id bigserial PRIMARY KEY,
+    data jsonb,
+    item_id text,
+    unique_identifier text,
+    source_url text,
+    first_seen timestamptz,
+    last_seen timestamptz,
+    removed_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS croatia_images (
+    id bigserial PRIMARY KEY,
+    item_id bigint,
+    extension text,
+    blob bytea
+);
+
+CREATE TABLE IF NOT EXISTS romania_scrapped (
+    id bigserial PRIMARY KEY,
+    data jsonb,
+    item_id text,
+    unique_identifier text,
+    source_url text,
+    first_seen timestamptz,
+    last_seen timestamptz,
+    removed_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS romania_images (
+    id bigserial PRIMARY KEY,
+    item_id bigint,
+    extension text,
+    blob bytea
+);
+
+ALTER TABLE croatia_scrapped ADD COLUMN IF NOT EXISTS source_url text;
+ALTER TABLE croatia_scrapped ADD COLUMN IF NOT EXISTS first_seen timestamptz;
+ALTER TABLE croatia_scrapped ADD COLUMN IF NOT EXISTS last_seen timestamptz;
+ALTER TABLE croatia_scrapped ADD COLUMN IF NOT EXISTS removed_at timestamptz;
+
+ALTER TABLE romania_scrapped ADD COLUMN IF NOT EXISTS source_url text;
+ALTER TABLE romania_scrapped ADD COLUMN IF NOT EXISTS first_seen timestamptz;
+ALTER TABLE romania_scrapped ADD COLUMN IF NOT EXISTS last_seen timestamptz;
+ALTER TABLE romania_scrapped ADD COLUMN IF NOT EXISTS removed_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS scrape_runs (
+    id bigserial PRIMARY KEY,
+    country text,
+    started_at timestamptz,
+    finished_at timestamptz,
+    complete boolean,
+    seen bigint,
+    failed bigint,
+    removed bigint
+);
+
+CREATE INDEX IF NOT EXISTS idx_scrape_runs_country ON scrape_runs (country);
+
+CREATE TABLE IF NOT EXISTS persons (
+    id bigserial PRIMARY KEY,
+    country text,
+    raw_id bigint,
+    item_id text,
+    source_url text,
+    name text,
+    last_name text,
+    gender text,
+    image_hash text,
+    dob text,
+    dob_date date,
+    dod text,
+    dod_date date,
+    pob text,
+    pod text,
+    pob_latitude double precision,
+    pob_longitude double precision,
+    pob_municipality text,
+    pob_county text,
+    pob_country_code text,
+    pob_geoname_id bigint,
+    pob_confidence double precision,
+    pod_latitude double precision,
+    pod_longitude double precision,
+    pod_municipality text,
+    pod_county text,
+    pod_country_code text,
+    pod_geoname_id bigint,
+    pod_confidence double precision,
+    age_at_disappearance bigint,
+    minor boolean,
+    elderly boolean,
+    first_seen timestamptz,
+    removed_at timestamptz,
+    data jsonb,
+    updated_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_persons_raw ON persons (country, raw_id);
+CREATE INDEX IF NOT EXISTS idx_persons_image_hash ON persons (image_hash);
+
+CREATE TABLE IF NOT EXISTS person_links (
+    id bigserial PRIMARY KEY,
+    country_a text,
+    item_id_a text,
+    country_b text,
+    item_id_b text,
+    score double precision,
+    reasons jsonb,
+    status text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    reviewed_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_person_links_pair ON person_links (country_a, item_id_a, country_b, item_id_b);
+CREATE INDEX IF NOT EXISTS idx_person_links_status ON person_links (status);
+
+CREATE TABLE IF NOT EXISTS gazetteer_places (
+    geoname_id bigint PRIMARY KEY,
+    name text,
+    ascii_name text,
+    alternate_names text,
+    latitude double precision,
+    longitude double precision,
+    feature_class text,
+    feature_code text,
+    country_code text,
+    admin1_code text,
+    admin2_code text,
+    population bigint
+);
+
+CREATE INDEX IF NOT EXISTS idx_gazetteer_places_country_code ON gazetteer_places (country_code);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_romania_images_item_id;
+DROP INDEX IF EXISTS romania_scrapped_unique_identifier_key;
+DROP INDEX IF EXISTS idx_croatia_images_item_id;
+DROP INDEX IF EXISTS croatia_scrapped_unique_identifier_key;
@@ -0,0 +1,8 @@
+ALTER TABLE scrape_runs DROP COLUMN unchanged;
+ALTER TABLE scrape_runs DROP COLUMN updated;
+ALTER TABLE scrape_runs DROP COLUMN created;
+
+DROP INDEX IF EXISTS romania_images_item_id_key;
+CREATE INDEX idx_romania_images_item_id ON romania_images (item_id);
+DROP INDEX IF EXISTS croatia_images_item_id_key;
+CREATE INDEX idx_croatia_images_item_id ON croatia_images (item_id);
@@ -0,0 +1,12 @@
+-- Images that were moved to the image store are not copied back, move them back before reverting.
+
+ALTER TABLE croatia_images DROP COLUMN height;
+ALTER TABLE croatia_images DROP COLUMN width;
+ALTER TABLE croatia_images DROP COLUMN mime_type;
+ALTER TABLE croatia_images DROP COLUMN size;
+
+ALTER TABLE romania_images DROP COLUMN height;
+ALTER TABLE romania_images DROP COLUMN width;
+ALTER TABLE romania_images DROP COLUMN mime_type;
+ALTER TABLE romania_images DROP COLUMN size;
+
@@ -0,0 +1,13 @@
+-- The image files are kept in the image store, the database only keeps what describes them. Existing blobs
+-- stay until they are moved with: images migrate
+
+ALTER TABLE croatia_images ADD COLUMN size bigint;
+ALTER TABLE croatia_images ADD COLUMN mime_type text;
+ALTER TABLE croatia_images ADD COLUMN width bigint;
+ALTER TABLE croatia_images ADD COLUMN height bigint;
+
+ALTER TABLE romania_images ADD COLUMN size bigint;
+ALTER TABLE romania_images ADD COLUMN mime_type text;
+ALTER TABLE romania_images ADD COLUMN width bigint;
+ALTER TABLE romania_images ADD COLUMN height bigint;
+
@@ -0,0 +1,16 @@
+-- Resized copies of the images, generated for every configured size (IMAGE_SIZES). The files are kept in
+-- the image store, source_hash is the hash of the image they were generated from.
+
+CREATE TABLE IF NOT EXISTS image_derivatives (
+    id bigserial PRIMARY KEY,
+    source_hash text NOT NULL,
+    size text NOT NULL,
+    max_size bigint NOT NULL,
+    hash text NOT NULL,
+    mime_type text,
+    width bigint,
+    height bigint,
+    created_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS image_derivatives_source_hash_size_key ON image_derivatives (source_hash, size);
@@ -0,0 +1,2 @@
+ALTER TABLE persons DROP COLUMN normalizer_version;
+ALTER TABLE persons DROP COLUMN parser_version;
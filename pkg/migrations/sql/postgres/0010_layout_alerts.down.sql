@@ -0,0 +1,2 @@
+ALTER TABLE scrape_runs DROP COLUMN layout_alert;
+ALTER TABLE scrape_runs DROP COLUMN layout_changed;
@@ -0,0 +1,5 @@
+-- A run that stopped because the pages of the site did not look as expected any more, and what was wrong
+-- with them
+
+ALTER TABLE scrape_runs ADD COLUMN layout_changed boolean NOT NULL DEFAULT false;
+ALTER TABLE scrape_runs ADD COLUMN layout_alert text NOT NULL DEFAULT '';
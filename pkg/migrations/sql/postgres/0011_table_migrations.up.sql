@@ -0,0 +1,15 @@
+-- The versions of the table templates in sql/postgres/tables applied to the tables of every country. The tables of
+-- croatia and romania were created by the migrations before 0011, they are the first version of the template.
+
+CREATE TABLE IF NOT EXISTS schema_table_migrations (
+    raw_table text NOT NULL,
+    images_table text NOT NULL,
+    version integer NOT NULL,
+    name text NOT NULL,
+    applied_at timestamptz NOT NULL,
+    PRIMARY KEY (raw_table, version)
+);
+
+INSERT INTO schema_table_migrations (raw_table, images_table, version, name, applied_at) VALUES
+    ('croatia_scrapped', 'croatia_images', 1, 'country_tables', CURRENT_TIMESTAMP),
+    ('romania_scrapped', 'romania_images', 1, 'country_tables', CURRENT_TIMESTAMP);
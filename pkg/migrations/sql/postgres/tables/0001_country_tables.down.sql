@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS {{.Images}};
+DROP TABLE IF EXISTS {{.Raw}};
@@ -0,0 +1,34 @@
+-- The tables of a country as the migrations 0001 to 0010 left the tables of croatia and romania.
+
+CREATE TABLE IF NOT EXISTS {{.Raw}} (
+    id bigserial PRIMARY KEY,
+    data jsonb,
+    item_id text,
+    unique_identifier text,
+    source_url text,
+    first_seen timestamptz,
+    last_seen timestamptz,
+    removed_at timestamptz,
+    missing_image_reason text,
+    parser_version bigint NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{.Raw}}_unique_identifier_key ON {{.Raw}} (unique_identifier);
+
+CREATE TABLE IF NOT EXISTS {{.Images}} (
+    id bigserial PRIMARY KEY,
+    item_id bigint,
+    extension text,
+    blob bytea,
+    hash text,
+    fetched_at timestamptz,
+    checked_at timestamptz,
+    size bigint,
+    mime_type text,
+    width bigint,
+    height bigint,
+    phash bigint
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{.Images}}_item_id_hash_key ON {{.Images}} (item_id, hash);
+CREATE INDEX IF NOT EXISTS idx_{{.Images}}_phash ON {{.Images}} (phash);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS gazetteer_places;
+DROP TABLE IF EXISTS person_links;
+DROP TABLE IF EXISTS persons;
+DROP TABLE IF EXISTS scrape_runs;
+DROP TABLE IF EXISTS romania_images;
+DROP TABLE IF EXISTS romania_scrapped;
+DROP TABLE IF EXISTS croatia_images;
+DROP TABLE IF EXISTS croatia_scrapped;
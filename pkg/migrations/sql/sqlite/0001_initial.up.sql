@@ -0,0 +1,126 @@
+-- The schema as it was created by gorm AutoMigrate.
+
+CREATE TABLE IF NOT EXISTS croatia_scrapped (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    data jsonb,
+    item_id text,
+    unique_identifier text,
+    source_url text,
+    first_seen datetime,
+    last_seen datetime,
+    removed_at datetime
+);
+
+CREATE TABLE IF NOT EXISTS croatia_images (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    item_id integer,
+    extension text,
+    blob blob
+);
+
+CREATE TABLE IF NOT EXISTS romania_scrapped (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    data jsonb,
+    item_id text,
+    unique_identifier text,
+    source_url text,
+    first_seen datetime,
+    last_seen datetime,
+    removed_at datetime
+);
+
+CREATE TABLE IF NOT EXISTS romania_images (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    item_id integer,
+    extension text,
+    blob blob
+);
+
+CREATE TABLE IF NOT EXISTS scrape_runs (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    country text,
+    started_at datetime,
+    finished_at datetime,
+    complete numeric,
+    seen integer,
+    failed integer,
+    removed integer
+);
+
+CREATE INDEX IF NOT EXISTS idx_scrape_runs_country ON scrape_runs (country);
+
+CREATE TABLE IF NOT EXISTS persons (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    country text,
+    raw_id integer,
+    item_id text,
+    source_url text,
+    name text,
+    last_name text,
+    gender text,
+    image_hash text,
+    dob text,
+    dob_date date,
+    dod text,
+    dod_date date,
+    pob text,
+    pod text,
+    pob_latitude real,
+    pob_longitude real,
+    pob_municipality text,
+    pob_county text,
+    pob_country_code text,
+    pob_geoname_id integer,
+    pob_confidence real,
+    pod_latitude real,
+    pod_longitude real,
+    pod_municipality text,
+    pod_county text,
+    pod_country_code text,
+    pod_geoname_id integer,
+    pod_confidence real,
+    age_at_disappearance integer,
+    minor numeric,
+    elderly numeric,
+    first_seen datetime,
+    removed_at datetime,
+    data jsonb,
+    updated_at datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_persons_raw ON persons (country, raw_id);
+CREATE INDEX IF NOT EXISTS idx_persons_image_hash ON persons (image_hash);
+
+CREATE TABLE IF NOT EXISTS person_links (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    country_a text,
+    item_id_a text,
+    country_b text,
+    item_id_b text,
+    score real,
+    reasons jsonb,
+    status text,
+    created_at datetime,
+    updated_at datetime,
+    reviewed_at datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_person_links_pair ON person_links (country_a, item_id_a, country_b, item_id_b);
+CREATE INDEX IF NOT EXISTS idx_person_links_status ON person_links (status);
+
+CREATE TABLE IF NOT EXISTS gazetteer_places (
+    geoname_id integer PRIMARY KEY,
+    name text,
+    ascii_name text,
+    alternate_names text,
+    latitude real,
+    longitude real,
+    feature_class text,
+    feature_code text,
+    country_code text,
+    admin1_code text,
+    admin2_code text,
+    population integer
+);
+
+CREATE INDEX IF NOT EXISTS idx_gazetteer_places_country_code ON gazetteer_places (country_code);
@@ -0,0 +1,16 @@
+-- Rows with the same unique identifier have the same scrapped data, only the oldest one is kept.
+
+DELETE FROM croatia_images WHERE item_id IN (
+    SELECT id FROM croatia_scrapped s WHERE id > (SELECT MIN(id) FROM croatia_scrapped o WHERE o.unique_identifier = s.unique_identifier)
+);
+DELETE FROM croatia_scrapped WHERE id > (SELECT MIN(id) FROM croatia_scrapped o WHERE o.unique_identifier = croatia_scrapped.unique_identifier);
+CREATE UNIQUE INDEX croatia_scrapped_unique_identifier_key ON croatia_scrapped (unique_identifier);
+CREATE INDEX idx_croatia_images_item_id ON croatia_images (item_id);
+
+DELETE FROM romania_images WHERE item_id IN (
+    SELECT id FROM romania_scrapped s WHERE id > (SELECT MIN(id) FROM romania_scrapped o WHERE o.unique_identifier = s.unique_identifier)
+);
+DELETE FROM romania_scrapped WHERE id > (SELECT MIN(id) FROM romania_scrapped o WHERE o.unique_identifier = romania_scrapped.unique_identifier);
+CREATE UNIQUE INDEX romania_scrapped_unique_identifier_key ON romania_scrapped (unique_identifier);
+CREATE INDEX idx_romania_images_item_id ON romania_images (item_id);
+
@@ -0,0 +1,14 @@
+-- A person has a single image, so images can be upserted on item_id. Only the newest image of a person is kept.
+
+DELETE FROM croatia_images WHERE id < (SELECT MAX(id) FROM croatia_images o WHERE o.item_id = croatia_images.item_id);
+DROP INDEX IF EXISTS idx_croatia_images_item_id;
+CREATE UNIQUE INDEX croatia_images_item_id_key ON croatia_images (item_id);
+
+DELETE FROM romania_images WHERE id < (SELECT MAX(id) FROM romania_images o WHERE o.item_id = romania_images.item_id);
+DROP INDEX IF EXISTS idx_romania_images_item_id;
+CREATE UNIQUE INDEX romania_images_item_id_key ON romania_images (item_id);
+
+-- how many persons of a run were created, updated or found unchanged
+ALTER TABLE scrape_runs ADD COLUMN created integer DEFAULT 0;
+ALTER TABLE scrape_runs ADD COLUMN updated integer DEFAULT 0;
+ALTER TABLE scrape_runs ADD COLUMN unchanged integer DEFAULT 0;
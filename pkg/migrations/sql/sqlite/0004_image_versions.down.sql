@@ -0,0 +1,18 @@
+-- Only the newest version of every image is kept.
+
+ALTER TABLE croatia_scrapped DROP COLUMN missing_image_reason;
+DELETE FROM croatia_images WHERE id < (SELECT MAX(id) FROM croatia_images o WHERE o.item_id = croatia_images.item_id);
+DROP INDEX IF EXISTS croatia_images_item_id_hash_key;
+CREATE UNIQUE INDEX croatia_images_item_id_key ON croatia_images (item_id);
+ALTER TABLE croatia_images DROP COLUMN checked_at;
+ALTER TABLE croatia_images DROP COLUMN fetched_at;
+ALTER TABLE croatia_images DROP COLUMN hash;
+
+ALTER TABLE romania_scrapped DROP COLUMN missing_image_reason;
+DELETE FROM romania_images WHERE id < (SELECT MAX(id) FROM romania_images o WHERE o.item_id = romania_images.item_id);
+DROP INDEX IF EXISTS romania_images_item_id_hash_key;
+CREATE UNIQUE INDEX romania_images_item_id_key ON romania_images (item_id);
+ALTER TABLE romania_images DROP COLUMN checked_at;
+ALTER TABLE romania_images DROP COLUMN fetched_at;
+ALTER TABLE romania_images DROP COLUMN hash;
+
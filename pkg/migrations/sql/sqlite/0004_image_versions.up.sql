@@ -0,0 +1,23 @@
+-- Every version of a person's image is kept, a version is identified by the sha256 of its content.
+-- SQLite cannot hash the existing images, they have no hash and the next run adds the current image as a new version.
+
+ALTER TABLE croatia_images ADD COLUMN hash text;
+-- first and last time this version was downloaded
+ALTER TABLE croatia_images ADD COLUMN fetched_at datetime;
+ALTER TABLE croatia_images ADD COLUMN checked_at datetime;
+UPDATE croatia_images SET fetched_at = CURRENT_TIMESTAMP, checked_at = CURRENT_TIMESTAMP;
+DROP INDEX IF EXISTS croatia_images_item_id_key;
+CREATE UNIQUE INDEX croatia_images_item_id_hash_key ON croatia_images (item_id, hash);
+-- why the person has no image, empty if the image was saved
+ALTER TABLE croatia_scrapped ADD COLUMN missing_image_reason text;
+
+ALTER TABLE romania_images ADD COLUMN hash text;
+-- first and last time this version was downloaded
+ALTER TABLE romania_images ADD COLUMN fetched_at datetime;
+ALTER TABLE romania_images ADD COLUMN checked_at datetime;
+UPDATE romania_images SET fetched_at = CURRENT_TIMESTAMP, checked_at = CURRENT_TIMESTAMP;
+DROP INDEX IF EXISTS romania_images_item_id_key;
+CREATE UNIQUE INDEX romania_images_item_id_hash_key ON romania_images (item_id, hash);
+-- why the person has no image, empty if the image was saved
+ALTER TABLE romania_scrapped ADD COLUMN missing_image_reason text;
+
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_persons_image_phash;
+DROP INDEX IF EXISTS idx_romania_images_phash;
+DROP INDEX IF EXISTS idx_croatia_images_phash;
+
+ALTER TABLE persons DROP COLUMN image_phash;
+ALTER TABLE romania_images DROP COLUMN phash;
+ALTER TABLE croatia_images DROP COLUMN phash;
@@ -0,0 +1,9 @@
+-- Perceptual hashes of the images, images saved before are hashed with: images phash
+
+ALTER TABLE croatia_images ADD COLUMN phash integer;
+ALTER TABLE romania_images ADD COLUMN phash integer;
+ALTER TABLE persons ADD COLUMN image_phash integer;
+
+CREATE INDEX IF NOT EXISTS idx_croatia_images_phash ON croatia_images (phash);
+CREATE INDEX IF NOT EXISTS idx_romania_images_phash ON romania_images (phash);
+CREATE INDEX IF NOT EXISTS idx_persons_image_phash ON persons (image_phash);
@@ -0,0 +1,2 @@
+ALTER TABLE romania_scrapped DROP COLUMN parser_version;
+ALTER TABLE croatia_scrapped DROP COLUMN parser_version;
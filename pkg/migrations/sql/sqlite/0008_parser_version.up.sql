@@ -0,0 +1,5 @@
+-- The version of the parser that produced the scrapped data, rows saved before are version 0. Rows are parsed
+-- again from the archived pages with: reparse
+
+ALTER TABLE croatia_scrapped ADD COLUMN parser_version integer NOT NULL DEFAULT 0;
+ALTER TABLE romania_scrapped ADD COLUMN parser_version integer NOT NULL DEFAULT 0;
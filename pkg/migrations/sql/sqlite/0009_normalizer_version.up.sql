@@ -0,0 +1,5 @@
+-- The versions of the parser and of the normalization of every person, persons normalized before are
+-- version 0 and are normalized again with: reprocess
+
+ALTER TABLE persons ADD COLUMN parser_version integer NOT NULL DEFAULT 0;
+ALTER TABLE persons ADD COLUMN normalizer_version integer NOT NULL DEFAULT 0;
@@ -0,0 +1,34 @@
+-- The tables of a country as the migrations 0001 to 0010 left the tables of croatia and romania.
+
+CREATE TABLE IF NOT EXISTS {{.Raw}} (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    data jsonb,
+    item_id text,
+    unique_identifier text,
+    source_url text,
+    first_seen datetime,
+    last_seen datetime,
+    removed_at datetime,
+    missing_image_reason text,
+    parser_version integer NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{.Raw}}_unique_identifier_key ON {{.Raw}} (unique_identifier);
+
+CREATE TABLE IF NOT EXISTS {{.Images}} (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    item_id integer,
+    extension text,
+    blob blob,
+    hash text,
+    fetched_at datetime,
+    checked_at datetime,
+    size integer,
+    mime_type text,
+    width integer,
+    height integer,
+    phash integer
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{.Images}}_item_id_hash_key ON {{.Images}} (item_id, hash);
+CREATE INDEX IF NOT EXISTS idx_{{.Images}}_phash ON {{.Images}} (phash);
@@ -0,0 +1,296 @@
+package migrations
+
+import (
+	"bytes"
+	"fmt"
+	"gorm.io/gorm"
+	"missing-persons-scrapper/pkg/storage"
+	"path"
+	"text/template"
+	"time"
+)
+
+/*
+*
+The tables of a country are created and changed by the templates in sql/<dialect>/tables, migrations where
+{{.Raw}} and {{.Images}} are the names of the tables (storage.Tables). Every set of tables records the templates
+applied to it in the schema_table_migrations table, so a country that is only defined in a definition file
+gets the same tables as croatia and romania and a new template is applied to the tables of every country.
+*/
+const Table_Migrations_Table = "schema_table_migrations"
+
+// A template applied to the tables of a country, a row of schema_table_migrations.
+type TablesApplied struct {
+	Raw       string    `gorm:"column:raw_table;primaryKey"`
+	Images    string    `gorm:"column:images_table"`
+	Version   int       `gorm:"column:version;primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"column:name"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (TablesApplied) TableName() string {
+	return Table_Migrations_Table
+}
+
+// The templates of the tables of a country and whether they are applied, as printed by migrate status.
+type TablesState struct {
+	Tables storage.Tables
+	States []State
+}
+
+// Returns the templates of the tables of a country of the database's dialect ordered by version.
+func LoadTables(db *gorm.DB) ([]Migration, error) {
+	migrations, err := loadDir(path.Join("sql", db.Dialector.Name(), "tables"))
+	if err != nil {
+		return nil, fmt.Errorf("no table migrations for %s: %w", db.Dialector.Name(), err)
+	}
+
+	return migrations, nil
+}
+
+/*
+*
+Applies every template that is not applied yet to the tables, the tables are created if they do not exist.
+Returns the number of applied templates.
+*/
+func UpTables(db *gorm.DB, tables storage.Tables) (int, error) {
+	migrations, applied, err := loadTables(db, tables)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := execTemplate(tx, m.Up, tables); err != nil {
+				return err
+			}
+
+			return tx.Create(&TablesApplied{Raw: tables.Raw, Images: tables.Images, Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+
+		if err != nil {
+			return count, fmt.Errorf("table migration %04d_%s of %s failed: %w", m.Version, m.Name, tables.Raw, err)
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+/*
+*
+Reverts the last steps templates applied to the tables of a country, raw is the name of its raw table.
+Reverting the first template drops the tables. Returns the number of reverted templates.
+*/
+func DownTables(db *gorm.DB, raw string, steps int) (int, error) {
+	tables, ok, err := findTables(db, raw)
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return 0, fmt.Errorf("no table migrations applied to %s", raw)
+	}
+
+	migrations, applied, err := loadTables(db, tables)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := execTemplate(tx, m.Down, tables); err != nil {
+				return err
+			}
+
+			return tx.Where("raw_table = ? AND version = ?", tables.Raw, m.Version).Delete(&TablesApplied{}).Error
+		})
+
+		if err != nil {
+			return count, fmt.Errorf("reverting table migration %04d_%s of %s failed: %w", m.Version, m.Name, tables.Raw, err)
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+// The templates of the tables of every country that has one applied, none before 0011_table_migrations.
+func TablesStatus(db *gorm.DB) ([]TablesState, error) {
+	if !db.Migrator().HasTable(Table_Migrations_Table) {
+		return nil, nil
+	}
+
+	all, err := trackedTables(db)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]TablesState, 0, len(all))
+	for _, tables := range all {
+		migrations, applied, err := loadTables(db, tables)
+		if err != nil {
+			return nil, err
+		}
+
+		states := make([]State, 0, len(migrations))
+		for _, m := range migrations {
+			state := State{Migration: m}
+			if a, ok := applied[m.Version]; ok {
+				appliedAt := a.AppliedAt
+				state.AppliedAt = &appliedAt
+			}
+
+			states = append(states, state)
+		}
+
+		result = append(result, TablesState{Tables: tables, States: states})
+	}
+
+	return result, nil
+}
+
+/*
+*
+Returns ErrOutdated if the tables have no template applied, the tables of a new definition are created by
+UpTables. The tables that have one are checked by Check.
+*/
+func CheckTables(db *gorm.DB, tables storage.Tables) error {
+	_, ok, err := findTables(db, tables.Raw)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: the tables %s and %s do not exist", ErrOutdated, tables.Raw, tables.Images)
+	}
+
+	return nil
+}
+
+// the templates every set of tables is missing, applied by Up
+func upTrackedTables(db *gorm.DB) (int, error) {
+	all, err := trackedTables(db)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, tables := range all {
+		n, err := UpTables(db, tables)
+		count += n
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
+
+// ErrOutdated if a set of tables is missing a template and ErrNewer if it has one this program does not know
+func checkTables(db *gorm.DB) error {
+	all, err := trackedTables(db)
+	if err != nil {
+		return err
+	}
+
+	for _, tables := range all {
+		migrations, applied, err := loadTables(db, tables)
+		if err != nil {
+			return err
+		}
+
+		known := make(map[int]bool, len(migrations))
+		for _, m := range migrations {
+			known[m.Version] = true
+			if _, ok := applied[m.Version]; !ok {
+				return fmt.Errorf("%w: %04d_%s of %s is not applied", ErrOutdated, m.Version, m.Name, tables.Raw)
+			}
+		}
+
+		for version, a := range applied {
+			if !known[version] {
+				return fmt.Errorf("%w: %04d_%s of %s is unknown", ErrNewer, version, a.Name, tables.Raw)
+			}
+		}
+	}
+
+	return nil
+}
+
+// the sets of tables that have a template applied, ordered by the name of the raw table
+func trackedTables(db *gorm.DB) ([]storage.Tables, error) {
+	var rows []TablesApplied
+	if err := db.Select("raw_table", "images_table").Distinct().Order("raw_table").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	all := make([]storage.Tables, len(rows))
+	for i, r := range rows {
+		all[i] = storage.Tables{Raw: r.Raw, Images: r.Images}
+	}
+
+	return all, nil
+}
+
+func findTables(db *gorm.DB, raw string) (storage.Tables, bool, error) {
+	all, err := trackedTables(db)
+	if err != nil {
+		return storage.Tables{}, false, err
+	}
+
+	for _, tables := range all {
+		if tables.Raw == raw {
+			return tables, true, nil
+		}
+	}
+
+	return storage.Tables{}, false, nil
+}
+
+func loadTables(db *gorm.DB, tables storage.Tables) ([]Migration, map[int]TablesApplied, error) {
+	migrations, err := LoadTables(db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []TablesApplied
+	if err := db.Where("raw_table = ?", tables.Raw).Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	applied := make(map[int]TablesApplied, len(rows))
+	for _, a := range rows {
+		applied[a.Version] = a
+	}
+
+	return migrations, applied, nil
+}
+
+// The table names are checked by the definitions (see the engine package) so they can be put in the SQL.
+func execTemplate(tx *gorm.DB, sql string, tables storage.Tables) error {
+	tmpl, err := template.New("tables").Parse(sql)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, tables); err != nil {
+		return err
+	}
+
+	return exec(tx, b.String())
+}
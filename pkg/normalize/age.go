@@ -0,0 +1,74 @@
+package normalize
+
+import "time"
+
+const (
+	// younger than this at the time of disappearance
+	MinorAge = 18
+	// at least this old at the time of disappearance
+	ElderlyAge = 65
+)
+
+/*
+*
+Ages change every day, so they are computed from the dates whenever they are read. A nil age means
+that it cannot be computed, usually because the date of birth is unknown.
+
+Dates with only a month or year are taken as the first day of that period, so those ages can be off by one.
+*/
+type Ages struct {
+	AgeAtDisappearance *int `json:"age_at_disappearance"`
+	CurrentAge         *int `json:"current_age"`
+	YearsMissing       *int `json:"years_missing"`
+	Minor              bool `json:"minor"`
+	Elderly            bool `json:"elderly"`
+}
+
+/*
+*
+If the date of disappearance is unknown, minor and elderly are decided by the current age.
+*/
+func NewAges(dob, dod Date, now time.Time) Ages {
+	ages := Ages{}
+
+	born, hasDOB := dob.Time()
+	missing, hasDOD := dod.Time()
+
+	if hasDOB {
+		ages.CurrentAge = yearsBetween(born, now)
+	}
+
+	if hasDOD {
+		ages.YearsMissing = yearsBetween(missing, now)
+	}
+
+	if hasDOB && hasDOD {
+		ages.AgeAtDisappearance = yearsBetween(born, missing)
+	}
+
+	age := ages.AgeAtDisappearance
+	if age == nil {
+		age = ages.CurrentAge
+	}
+
+	if age != nil {
+		ages.Minor = *age < MinorAge
+		ages.Elderly = *age >= ElderlyAge
+	}
+
+	return ages
+}
+
+// full years from until to, nil if to is before from
+func yearsBetween(from, to time.Time) *int {
+	if to.Before(from) {
+		return nil
+	}
+
+	years := to.Year() - from.Year()
+	if to.Month() < from.Month() || (to.Month() == from.Month() && to.Day() < from.Day()) {
+		years--
+	}
+
+	return &years
+}
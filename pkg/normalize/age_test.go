@@ -0,0 +1,35 @@
+package normalize
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewAges(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	ages := NewAges(ParseDate("12.03.2010."), ParseDate("02.03.2020."), now)
+	assert.Equal(t, 9, *ages.AgeAtDisappearance)
+	assert.Equal(t, 14, *ages.CurrentAge)
+	assert.Equal(t, 4, *ages.YearsMissing)
+	assert.True(t, ages.Minor)
+	assert.False(t, ages.Elderly)
+
+	ages = NewAges(ParseDate("1950."), ParseDate("12 martie 2021"), now)
+	assert.Equal(t, 71, *ages.AgeAtDisappearance)
+	assert.True(t, ages.Elderly)
+	assert.False(t, ages.Minor)
+
+	// without the date of disappearance the current age decides
+	ages = NewAges(ParseDate("01.01.2010."), ParseDate(""), now)
+	assert.Nil(t, ages.AgeAtDisappearance)
+	assert.Nil(t, ages.YearsMissing)
+	assert.True(t, ages.Minor)
+
+	ages = NewAges(ParseDate("nepoznato"), ParseDate("2020."), now)
+	assert.Nil(t, ages.CurrentAge)
+	assert.Equal(t, 4, *ages.YearsMissing)
+	assert.False(t, ages.Minor)
+	assert.False(t, ages.Elderly)
+}
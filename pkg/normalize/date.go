@@ -0,0 +1,164 @@
+package normalize
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Precision string
+
+const (
+	PrecisionNone  Precision = ""
+	PrecisionYear  Precision = "year"
+	PrecisionMonth Precision = "month"
+	PrecisionDay   Precision = "day"
+)
+
+/*
+*
+A date as the official sites show it. Value is an ISO 8601 date cut to its precision
+("1987-03-12", "1987-03" or "1987"). If the original text could not be understood, Valid
+is false and only Original is set.
+*/
+type Date struct {
+	Value     string    `json:"value"`
+	Precision Precision `json:"precision"`
+	Original  string    `json:"original"`
+	Valid     bool      `json:"valid"`
+
+	year  int
+	month time.Month
+	day   int
+}
+
+// month names in Croatian (nominative and genitive) and Romanian, without diacritics
+var months = map[string]time.Month{
+	"sijecanj": time.January, "sijecnja": time.January, "ianuarie": time.January, "ian": time.January,
+	"veljaca": time.February, "veljace": time.February, "februarie": time.February, "feb": time.February,
+	"ozujak": time.March, "ozujka": time.March, "martie": time.March, "mar": time.March,
+	"travanj": time.April, "travnja": time.April, "aprilie": time.April, "apr": time.April,
+	"svibanj": time.May, "svibnja": time.May, "mai": time.May,
+	"lipanj": time.June, "lipnja": time.June, "iunie": time.June, "iun": time.June,
+	"srpanj": time.July, "srpnja": time.July, "iulie": time.July, "iul": time.July,
+	"kolovoz": time.August, "kolovoza": time.August, "august": time.August, "aug": time.August,
+	"rujan": time.September, "rujna": time.September, "septembrie": time.September, "sep": time.September, "sept": time.September,
+	"listopad": time.October, "listopada": time.October, "octombrie": time.October, "oct": time.October,
+	"studeni": time.November, "studenoga": time.November, "studenog": time.November, "noiembrie": time.November, "nov": time.November,
+	"prosinac": time.December, "prosinca": time.December, "decembrie": time.December, "dec": time.December,
+}
+
+var (
+	isoDate      = regexp.MustCompile(`\b(\d{4})-(\d{1,2})-(\d{1,2})\b`)
+	numericDate  = regexp.MustCompile(`\b(\d{1,2})\s*[./-]\s*(\d{1,2})\s*[./-]\s*(\d{4}|\d{2})\b`)
+	textDate     = regexp.MustCompile(`\b(\d{1,2})\.?\s+([a-z]+)\.?,?\s+(\d{4})\b`)
+	numericMonth = regexp.MustCompile(`\b(\d{1,2})\s*[./]\s*(\d{4})\b`)
+	textMonth    = regexp.MustCompile(`\b([a-z]+)\.?,?\s+(\d{4})\b`)
+	yearOnly     = regexp.MustCompile(`\b(1[89]\d{2}|20\d{2})\b`)
+)
+
+/*
+*
+Parses a Croatian or Romanian date ("12.03.1987.", "12. ožujka 1987.", "12 martie 1987",
+"03.1987.", "1987."). The first date found in the text is used, so trailing text like the
+time of disappearance is ignored.
+*/
+func ParseDate(value string) Date {
+	d := Date{Original: value}
+
+	text := strings.TrimSpace(Fold(value))
+	if text == "" {
+		return d
+	}
+
+	if m := isoDate.FindStringSubmatch(text); m != nil {
+		return d.with(atoi(m[1]), atoi(m[2]), atoi(m[3]), PrecisionDay)
+	}
+
+	if m := numericDate.FindStringSubmatch(text); m != nil {
+		return d.with(fullYear(m[3]), atoi(m[2]), atoi(m[1]), PrecisionDay)
+	}
+
+	if m := textDate.FindStringSubmatch(text); m != nil {
+		if month, ok := months[m[2]]; ok {
+			return d.with(atoi(m[3]), int(month), atoi(m[1]), PrecisionDay)
+		}
+	}
+
+	if m := numericMonth.FindStringSubmatch(text); m != nil {
+		return d.with(atoi(m[2]), atoi(m[1]), 1, PrecisionMonth)
+	}
+
+	for _, m := range textMonth.FindAllStringSubmatch(text, -1) {
+		if month, ok := months[m[1]]; ok {
+			return d.with(atoi(m[2]), int(month), 1, PrecisionMonth)
+		}
+	}
+
+	if m := yearOnly.FindStringSubmatch(text); m != nil {
+		return d.with(atoi(m[1]), 1, 1, PrecisionYear)
+	}
+
+	return d
+}
+
+/*
+*
+Returns the first day of the period the date describes (the 1st of the month for month precision,
+January 1st for year precision).
+*/
+func (d Date) Time() (time.Time, bool) {
+	if !d.Valid {
+		return time.Time{}, false
+	}
+
+	return time.Date(d.year, d.month, d.day, 0, 0, 0, 0, time.UTC), true
+}
+
+func (d Date) with(year, month, day int, precision Precision) Date {
+	if year < 1800 || year > 2100 || month < 1 || month > 12 {
+		return d
+	}
+
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	// time.Date normalizes overflowing days (31.02. becomes 03.03.), which means the date does not exist
+	if t.Day() != day {
+		return d
+	}
+
+	d.year, d.month, d.day = year, time.Month(month), day
+	d.Precision = precision
+	d.Valid = true
+
+	switch precision {
+	case PrecisionDay:
+		d.Value = t.Format("2006-01-02")
+	case PrecisionMonth:
+		d.Value = t.Format("2006-01")
+	case PrecisionYear:
+		d.Value = fmt.Sprintf("%04d", year)
+	}
+
+	return d
+}
+
+// two digit years are in the past century if they would otherwise be in the future
+func fullYear(year string) int {
+	y := atoi(year)
+	if len(year) != 2 {
+		return y
+	}
+
+	if 2000+y > time.Now().Year() {
+		return 1900 + y
+	}
+
+	return 2000 + y
+}
+
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
@@ -0,0 +1,41 @@
+package normalize
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseDate(t *testing.T) {
+	cases := []struct {
+		input     string
+		value     string
+		precision Precision
+		valid     bool
+	}{
+		{"12.03.1987.", "1987-03-12", PrecisionDay, true},
+		{"12.03.1987", "1987-03-12", PrecisionDay, true},
+		{"2. 3. 1987.", "1987-03-02", PrecisionDay, true},
+		{"12/03/87", "1987-03-12", PrecisionDay, true},
+		{"1987-03-12", "1987-03-12", PrecisionDay, true},
+		{"12 martie 1987", "1987-03-12", PrecisionDay, true},
+		{"12. ožujka 1987.", "1987-03-12", PrecisionDay, true},
+		{"1 decembrie 2019, ora 14:00", "2019-12-01", PrecisionDay, true},
+		{"12.03.2020. u 14:30 sati", "2020-03-12", PrecisionDay, true},
+		{"03.1987.", "1987-03", PrecisionMonth, true},
+		{"martie 1987", "1987-03", PrecisionMonth, true},
+		{"Studeni 2001.", "2001-11", PrecisionMonth, true},
+		{"1987.", "1987", PrecisionYear, true},
+		{"1987", "1987", PrecisionYear, true},
+		{"31.02.1987.", "", PrecisionNone, false},
+		{"nepoznato", "", PrecisionNone, false},
+		{"", "", PrecisionNone, false},
+	}
+
+	for _, c := range cases {
+		d := ParseDate(c.input)
+		assert.Equal(t, c.value, d.Value, c.input)
+		assert.Equal(t, c.precision, d.Precision, c.input)
+		assert.Equal(t, c.valid, d.Valid, c.input)
+		assert.Equal(t, c.input, d.Original, c.input)
+	}
+}
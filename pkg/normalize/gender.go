@@ -0,0 +1,33 @@
+package normalize
+
+type Gender string
+
+const (
+	GenderUnknown Gender = ""
+	GenderMale    Gender = "male"
+	GenderFemale  Gender = "female"
+)
+
+type GenderValue struct {
+	Value    Gender `json:"value"`
+	Original string `json:"original"`
+}
+
+// Croatian and Romanian words (and their abbreviations) for the gender, without diacritics
+var genders = map[string]Gender{
+	"m": GenderMale, "muski": GenderMale, "musko": GenderMale, "masculin": GenderMale, "barbat": GenderMale, "barbatesc": GenderMale,
+	"z": GenderFemale, "f": GenderFemale, "zenski": GenderFemale, "zensko": GenderFemale, "feminin": GenderFemale, "femeie": GenderFemale, "femeiesc": GenderFemale,
+}
+
+func ParseGender(value string) GenderValue {
+	g := GenderValue{Original: value}
+
+	for _, word := range words.FindAllString(Fold(value), -1) {
+		if gender, ok := genders[word]; ok {
+			g.Value = gender
+			break
+		}
+	}
+
+	return g
+}
@@ -0,0 +1,56 @@
+package normalize
+
+import "missing-persons-scrapper/pkg/htmlParser"
+
+/*
+*
+The version of the normalization, saved with every normalized person. Increase it when a change of this
+package changes the normalized values, reprocess then normalizes every person again.
+*/
+const Version = 1
+
+/*
+*
+A person with every value that can be compared between countries normalized. The original text of
+dates, measurements and colours stays in the values themselves.
+*/
+type Person struct {
+	Name        string      `json:"name"`
+	LastName    string      `json:"last_name"`
+	MaidenName  string      `json:"maiden_name"`
+	Gender      GenderValue `json:"gender"`
+	Citizenship string      `json:"citizenship"`
+
+	DOB Date   `json:"dob"`
+	POB string `json:"pob"`
+	DOD Date   `json:"dod"`
+	POD string `json:"pod"`
+
+	Physical
+	Build               string `json:"build"`
+	DistinguishingMarks string `json:"distinguishing_marks"`
+	Clothing            string `json:"clothing"`
+	Description         string `json:"description"`
+
+	Confidence map[string]float64 `json:"confidence,omitempty"`
+}
+
+func NewPerson(raw htmlParser.RawPerson) Person {
+	return Person{
+		Name:                raw.Name,
+		LastName:            raw.LastName,
+		MaidenName:          raw.MaidenName,
+		Gender:              ParseGender(raw.Gender),
+		Citizenship:         raw.Citizenship,
+		DOB:                 ParseDate(raw.DOB),
+		POB:                 raw.POB,
+		DOD:                 ParseDate(raw.DOD),
+		POD:                 raw.POD,
+		Physical:            NewPhysical(raw),
+		Build:               raw.Build,
+		DistinguishingMarks: raw.DistinguishingMarks,
+		Clothing:            raw.Clothing,
+		Description:         raw.Description,
+		Confidence:          raw.Confidence,
+	}
+}
@@ -0,0 +1,230 @@
+package normalize
+
+import (
+	"missing-persons-scrapper/pkg/htmlParser"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+*
+A height (cm) or weight (kg). Sites sometimes give a range ("170-180 cm"), in which case
+Min and Max differ, otherwise they are the same value.
+*/
+type Measurement struct {
+	Min      int    `json:"min"`
+	Max      int    `json:"max"`
+	Unit     string `json:"unit"`
+	Original string `json:"original"`
+	Valid    bool   `json:"valid"`
+}
+
+type Colour string
+
+const (
+	ColourUnknown Colour = ""
+	ColourBlack   Colour = "black"
+	ColourBrown   Colour = "brown"
+	ColourBlonde  Colour = "blonde"
+	ColourRed     Colour = "red"
+	ColourGrey    Colour = "grey"
+	ColourWhite   Colour = "white"
+	ColourBald    Colour = "bald"
+	ColourBlue    Colour = "blue"
+	ColourGreen   Colour = "green"
+	ColourHazel   Colour = "hazel"
+)
+
+// A hair or eye colour mapped to the canonical enumeration with the text from the site preserved.
+type ColourValue struct {
+	Value    Colour `json:"value"`
+	Original string `json:"original"`
+}
+
+/*
+*
+Word stems (lowercase, without diacritics) of Croatian and Romanian colour words. Stems are matched
+against the beginning of every word, so "smed" matches "smeđa", "smeđe" and "smeđi". Longer stems are
+checked first, which is why "albastr" wins over "alb".
+
+"plav" is blonde for hair but blue for eyes in Croatian.
+*/
+var hairColours = map[string]Colour{
+	"crn": ColourBlack, "negr": ColourBlack, "neagr": ColourBlack, "brunet": ColourBlack,
+	"smed": ColourBrown, "kesten": ColourBrown, "saten": ColourBrown, "castan": ColourBrown,
+	"plav": ColourBlonde, "blond": ColourBlonde,
+	"rid": ColourRed, "crven": ColourRed, "roscat": ColourRed, "rosu": ColourRed,
+	"sijed": ColourGrey, "prosijed": ColourGrey, "carunt": ColourGrey, "grizonat": ColourGrey, "sur": ColourGrey,
+	"bijel": ColourWhite, "alb": ColourWhite,
+	"celav": ColourBald, "chel": ColourBald,
+}
+
+var eyeColours = map[string]Colour{
+	"crn": ColourBlack, "negr": ColourBlack,
+	"smed": ColourBrown, "kesten": ColourBrown, "caprui": ColourBrown, "maro": ColourBrown,
+	"plav": ColourBlue, "albastr": ColourBlue, "albast": ColourBlue,
+	"zelen": ColourGreen, "verz": ColourGreen, "verde": ColourGreen,
+	"siv": ColourGrey, "gri": ColourGrey, "cenus": ColourGrey,
+	"ljeskast": ColourHazel, "alune": ColourHazel,
+}
+
+// The physical description of a person in units and vocabulary that are the same for every country.
+type Physical struct {
+	Height Measurement `json:"height"`
+	Weight Measurement `json:"weight"`
+	Hair   ColourValue `json:"hair"`
+	Eyes   ColourValue `json:"eyes"`
+}
+
+func NewPhysical(p htmlParser.RawPerson) Physical {
+	return Physical{
+		Height: ParseHeight(p.Height),
+		Weight: ParseWeight(p.Weight),
+		Hair:   ParseHairColour(p.Hair),
+		Eyes:   ParseEyeColour(p.EyeColor),
+	}
+}
+
+var (
+	measurementNumbers = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+	unitMeters         = regexp.MustCompile(`\d\s*m\b|\bmetr`)
+	unitCentimeters    = regexp.MustCompile(`\d\s*cm\b`)
+	words              = regexp.MustCompile(`[a-z]+`)
+)
+
+// Parses "175 cm", "1,75 m", "cca 175", "170-180 cm", "175 cm (1,75 m)" into centimeters.
+func ParseHeight(value string) Measurement {
+	m := Measurement{Unit: "cm", Original: value}
+
+	text := strings.ToLower(value)
+	numbers := parseNumbers(text)
+	if len(numbers) == 0 {
+		return m
+	}
+
+	// with both units the numbers in centimeters are the ones above 3
+	meters := unitMeters.MatchString(text) && !unitCentimeters.MatchString(text)
+	for i, n := range numbers {
+		// "1,75 m" or just "1,75"
+		if meters || n < 3 {
+			numbers[i] = n * 100
+		}
+	}
+
+	return m.with(numbers, 40, 250)
+}
+
+// Parses "80 kg", "cca 80 kg", "70-80 kg" into kilograms.
+func ParseWeight(value string) Measurement {
+	m := Measurement{Unit: "kg", Original: value}
+
+	numbers := parseNumbers(strings.ToLower(value))
+	if len(numbers) == 0 {
+		return m
+	}
+
+	return m.with(numbers, 2, 300)
+}
+
+func ParseHairColour(value string) ColourValue {
+	return parseColour(value, hairColours)
+}
+
+/*
+*
+Green and brown together ("zeleno-smeđe", "verzi-căprui") are hazel.
+*/
+func ParseEyeColour(value string) ColourValue {
+	c := ColourValue{Original: value}
+
+	found := findColours(value, eyeColours)
+	if contains(found, ColourGreen) && contains(found, ColourBrown) {
+		c.Value = ColourHazel
+	} else if len(found) > 0 {
+		c.Value = found[0]
+	}
+
+	return c
+}
+
+func parseColour(value string, vocabulary map[string]Colour) ColourValue {
+	c := ColourValue{Original: value}
+
+	if found := findColours(value, vocabulary); len(found) > 0 {
+		c.Value = found[0]
+	}
+
+	return c
+}
+
+func findColours(value string, vocabulary map[string]Colour) []Colour {
+	stems := make([]string, 0, len(vocabulary))
+	for stem := range vocabulary {
+		stems = append(stems, stem)
+	}
+
+	sort.Slice(stems, func(i, j int) bool {
+		if len(stems[i]) == len(stems[j]) {
+			return stems[i] < stems[j]
+		}
+		return len(stems[i]) > len(stems[j])
+	})
+
+	found := make([]Colour, 0)
+	text := Fold(value)
+	for _, word := range words.FindAllString(text, -1) {
+		for _, stem := range stems {
+			if strings.HasPrefix(word, stem) {
+				if !contains(found, vocabulary[stem]) {
+					found = append(found, vocabulary[stem])
+				}
+				break
+			}
+		}
+	}
+
+	return found
+}
+
+func parseNumbers(text string) []float64 {
+	numbers := make([]float64, 0)
+	for _, n := range measurementNumbers.FindAllString(text, -1) {
+		f, err := strconv.ParseFloat(strings.Replace(n, ",", ".", 1), 64)
+		if err == nil {
+			numbers = append(numbers, f)
+		}
+	}
+
+	return numbers
+}
+
+func (m Measurement) with(numbers []float64, min, max int) Measurement {
+	low, high := numbers[0], numbers[0]
+	if len(numbers) > 1 {
+		high = numbers[1]
+	}
+
+	if high < low {
+		low, high = high, low
+	}
+
+	m.Min, m.Max = int(low+0.5), int(high+0.5)
+	if m.Min < min || m.Max > max {
+		return Measurement{Unit: m.Unit, Original: m.Original}
+	}
+
+	m.Valid = true
+	return m
+}
+
+func contains(colours []Colour, c Colour) bool {
+	for _, colour := range colours {
+		if colour == c {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+package normalize
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseHeight(t *testing.T) {
+	cases := []struct {
+		input    string
+		min, max int
+		valid    bool
+	}{
+		{"175 cm", 175, 175, true},
+		{"1,75 m", 175, 175, true},
+		{"1.70m", 170, 170, true},
+		{"cca 180", 180, 180, true},
+		{"170-180 cm", 170, 180, true},
+		{"1,70 - 1,75 m", 170, 175, true},
+		{"înălțime 1,65", 165, 165, true},
+		{"nepoznato", 0, 0, false},
+		{"1750 cm", 0, 0, false},
+		{"175 cm (1,75 m)", 175, 175, true},
+		{"1,75 m (175 cm)", 175, 175, true},
+	}
+
+	for _, c := range cases {
+		m := ParseHeight(c.input)
+		assert.Equal(t, c.min, m.Min, c.input)
+		assert.Equal(t, c.max, m.Max, c.input)
+		assert.Equal(t, c.valid, m.Valid, c.input)
+		assert.Equal(t, "cm", m.Unit, c.input)
+	}
+}
+
+func TestParseWeight(t *testing.T) {
+	cases := []struct {
+		input    string
+		min, max int
+		valid    bool
+	}{
+		{"80 kg", 80, 80, true},
+		{"cca 80 kg", 80, 80, true},
+		{"70-80 kg", 70, 80, true},
+		{"aprox. 65,5 kg", 66, 66, true},
+		{"", 0, 0, false},
+	}
+
+	for _, c := range cases {
+		m := ParseWeight(c.input)
+		assert.Equal(t, c.min, m.Min, c.input)
+		assert.Equal(t, c.max, m.Max, c.input)
+		assert.Equal(t, c.valid, m.Valid, c.input)
+	}
+}
+
+func TestParseColours(t *testing.T) {
+	hair := map[string]Colour{
+		"smeđa":         ColourBrown,
+		"tamno smeđa":   ColourBrown,
+		"castaniu":      ColourBrown,
+		"șaten":         ColourBrown,
+		"plava":         ColourBlonde,
+		"blondă":        ColourBlonde,
+		"crna":          ColourBlack,
+		"negru":         ColourBlack,
+		"sijeda":        ColourGrey,
+		"cărunt":        ColourGrey,
+		"alb":           ColourWhite,
+		"riđa":          ColourRed,
+		"nema podataka": ColourUnknown,
+	}
+
+	for input, expected := range hair {
+		c := ParseHairColour(input)
+		assert.Equal(t, expected, c.Value, input)
+		assert.Equal(t, input, c.Original, input)
+	}
+
+	eyes := map[string]Colour{
+		"plave":         ColourBlue,
+		"albaștri":      ColourBlue,
+		"căprui":        ColourBrown,
+		"smeđe":         ColourBrown,
+		"zelene":        ColourGreen,
+		"verzi":         ColourGreen,
+		"zeleno-smeđe":  ColourHazel,
+		"verzi-căprui":  ColourHazel,
+		"sive":          ColourGrey,
+		"nema podataka": ColourUnknown,
+	}
+
+	for input, expected := range eyes {
+		assert.Equal(t, expected, ParseEyeColour(input).Value, input)
+	}
+}
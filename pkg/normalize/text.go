@@ -0,0 +1,47 @@
+package normalize
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+*
+Lowercases the text and removes diacritics. Every rune is mapped to exactly one rune, so positions
+(in runes) in the folded text are the same as in the original.
+*/
+func Fold(text string) string {
+	folded := make([]rune, 0, len(text))
+	for _, r := range text {
+		r = unicode.ToLower(r)
+		if f, ok := foldedRunes[r]; ok {
+			r = f
+		} else if r > unicode.MaxASCII {
+			r = baseRune(r)
+		}
+
+		folded = append(folded, r)
+	}
+
+	return string(folded)
+}
+
+var foldedRunes = map[rune]rune{
+	'č': 'c', 'ć': 'c', 'đ': 'd', 'š': 's', 'ž': 'z',
+	'ă': 'a', 'â': 'a', 'î': 'i', 'ș': 's', 'ş': 's', 'ț': 't', 'ţ': 't', 'ã': 'a',
+}
+
+// the letter without its combining marks ("á" is "a" followed by an acute accent)
+func baseRune(r rune) rune {
+	decomposed := norm.NFD.String(string(r))
+
+	base, size := utf8.DecodeRuneInString(decomposed)
+	for _, mark := range decomposed[size:] {
+		if !unicode.Is(unicode.Mn, mark) {
+			return r
+		}
+	}
+
+	return base
+}
@@ -0,0 +1,51 @@
+package persons
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{
+	"country", "item_id", "name", "last_name", "gender", "dob", "pob", "dod", "pod",
+	"age_at_disappearance", "current_age", "years_missing", "minor", "elderly", "removed_at", "source_url",
+}
+
+// Writes the persons as CSV with the ages computed at now.
+func WriteCSV(w io.Writer, persons []Person, now time.Time) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, p := range persons {
+		ages := p.Ages(now)
+
+		removedAt := ""
+		if p.RemovedAt != nil {
+			removedAt = p.RemovedAt.Format(time.RFC3339)
+		}
+
+		row := []string{
+			p.Country, p.ItemID, p.Name, p.LastName, p.Gender, p.DOB, p.POB, p.DOD, p.POD,
+			optionalInt(ages.AgeAtDisappearance), optionalInt(ages.CurrentAge), optionalInt(ages.YearsMissing),
+			strconv.FormatBool(ages.Minor), strconv.FormatBool(ages.Elderly), removedAt, p.SourceURL,
+		}
+
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func optionalInt(i *int) string {
+	if i == nil {
+		return ""
+	}
+
+	return strconv.Itoa(*i)
+}
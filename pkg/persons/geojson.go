@@ -0,0 +1,129 @@
+package persons
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+)
+
+type geometry struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+type feature struct {
+	Type       string         `json:"type"`
+	Geometry   geometry       `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+/*
+*
+Writes the persons with a geocoded place of disappearance as a GeoJSON FeatureCollection of points.
+Persons without coordinates are left out.
+*/
+func WriteGeoJSON(w io.Writer, persons []Person, now time.Time) error {
+	collection := newFeatureCollection()
+
+	for _, p := range persons {
+		if p.PODPlace.Latitude == nil || p.PODPlace.Longitude == nil {
+			continue
+		}
+
+		ages := p.Ages(now)
+		collection.Features = append(collection.Features, newFeature(*p.PODPlace.Longitude, *p.PODPlace.Latitude, map[string]any{
+			"country":              p.Country,
+			"item_id":              p.ItemID,
+			"name":                 p.Name,
+			"last_name":            p.LastName,
+			"dod":                  p.DOD,
+			"pod":                  p.POD,
+			"municipality":         p.PODPlace.Municipality,
+			"county":               p.PODPlace.County,
+			"confidence":           p.PODPlace.Confidence,
+			"age_at_disappearance": ages.AgeAtDisappearance,
+			"minor":                ages.Minor,
+			"removed":              p.RemovedAt != nil,
+			"source_url":           p.SourceURL,
+		}))
+	}
+
+	return json.NewEncoder(w).Encode(collection)
+}
+
+/*
+*
+Writes one point per county of disappearance with the number of persons, for heatmaps. The point is the
+average of the places of disappearance in the county. Every person is counted, so they should have one row
+each, as Find reads them.
+*/
+func WriteCountiesGeoJSON(w io.Writer, persons []Person, now time.Time) error {
+	type county struct {
+		country, name       string
+		latitude, longitude float64
+		count, minors       int
+	}
+
+	counties := make(map[string]*county)
+	for _, p := range persons {
+		place := p.PODPlace
+		if place.Latitude == nil || place.Longitude == nil || place.County == "" {
+			continue
+		}
+
+		key := place.CountryCode + ":" + place.County
+		c, ok := counties[key]
+		if !ok {
+			c = &county{country: place.CountryCode, name: place.County}
+			counties[key] = c
+		}
+
+		c.latitude += *place.Latitude
+		c.longitude += *place.Longitude
+		c.count++
+
+		if p.Ages(now).Minor {
+			c.minors++
+		}
+	}
+
+	keys := make([]string, 0, len(counties))
+	for k := range counties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	collection := newFeatureCollection()
+	for _, k := range keys {
+		c := counties[k]
+		n := float64(c.count)
+
+		collection.Features = append(collection.Features, newFeature(c.longitude/n, c.latitude/n, map[string]any{
+			"country_code": c.country,
+			"county":       c.name,
+			"count":        c.count,
+			"minors":       c.minors,
+		}))
+	}
+
+	return json.NewEncoder(w).Encode(collection)
+}
+
+func newFeatureCollection() featureCollection {
+	return featureCollection{Type: "FeatureCollection", Features: make([]feature, 0)}
+}
+
+// GeoJSON coordinates are longitude first
+func newFeature(longitude, latitude float64, properties map[string]any) feature {
+	return feature{
+		Type:       "Feature",
+		Geometry:   geometry{Type: "Point", Coordinates: [2]float64{longitude, latitude}},
+		Properties: properties,
+	}
+}
@@ -0,0 +1,52 @@
+package persons
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func located(country, itemID, county string, lat, lon float64) Person {
+	return Person{
+		Country:  country,
+		ItemID:   itemID,
+		PODPlace: Place{Latitude: &lat, Longitude: &lon, County: county, CountryCode: "HR"},
+	}
+}
+
+func TestWriteGeoJSON(t *testing.T) {
+	persons := []Person{
+		located("hr", "1", "Grad Zagreb", 45.8, 15.9),
+		{Country: "hr", ItemID: "2"},
+	}
+
+	buff := &bytes.Buffer{}
+	assert.Nil(t, WriteGeoJSON(buff, persons, time.Now()))
+
+	var collection featureCollection
+	assert.Nil(t, json.Unmarshal(buff.Bytes(), &collection))
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	assert.Len(t, collection.Features, 1)
+	assert.Equal(t, [2]float64{15.9, 45.8}, collection.Features[0].Geometry.Coordinates)
+	assert.Equal(t, "1", collection.Features[0].Properties["item_id"])
+}
+
+func TestWriteCountiesGeoJSON(t *testing.T) {
+	persons := []Person{
+		located("hr", "1", "Grad Zagreb", 45.8, 15.9),
+		located("hr", "2", "Grad Zagreb", 45.6, 16.1),
+		located("hr", "3", "Splitsko-Dalmatinska", 43.5, 16.4),
+	}
+
+	buff := &bytes.Buffer{}
+	assert.Nil(t, WriteCountiesGeoJSON(buff, persons, time.Now()))
+
+	var collection featureCollection
+	assert.Nil(t, json.Unmarshal(buff.Bytes(), &collection))
+	assert.Len(t, collection.Features, 2)
+	assert.Equal(t, "Grad Zagreb", collection.Features[0].Properties["county"])
+	assert.Equal(t, 2.0, collection.Features[0].Properties["count"])
+	assert.InDelta(t, 45.7, collection.Features[0].Geometry.Coordinates[1], 0.0001)
+}
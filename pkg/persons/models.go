@@ -0,0 +1,165 @@
+package persons
+
+import (
+	"encoding/json"
+	"gorm.io/datatypes"
+	"missing-persons-scrapper/pkg/geocode"
+	"missing-persons-scrapper/pkg/normalize"
+	"time"
+)
+
+const Persons_Table = "persons"
+
+/*
+*
+The normalized version of a scrapped person, one row per raw row of every country. Columns hold the
+values that can be filtered on, Data holds the whole normalize.Person with the original texts.
+
+Age at disappearance does not change so it is stored, every other age is computed when the person is read.
+*/
+type Person struct {
+	ID        int
+	Country   string `gorm:"column:country;uniqueIndex:idx_persons_raw"`
+	RawID     int    `gorm:"column:raw_id;uniqueIndex:idx_persons_raw"`
+	ItemID    string `gorm:"column:item_id"`
+	SourceURL string `gorm:"column:source_url"`
+
+	Name     string `gorm:"column:name"`
+	LastName string `gorm:"column:last_name"`
+	Gender   string `gorm:"column:gender"`
+
+	// sha256 of the current image, empty if the person has no image
+	ImageHash string `gorm:"column:image_hash;index"`
+	// perceptual hash of the current image, see imagefile.PerceptualHash
+	ImagePHash *int64 `gorm:"column:image_phash;index"`
+
+	DOB     string     `gorm:"column:dob"`
+	DOBDate *time.Time `gorm:"column:dob_date;type:date"`
+	DOD     string     `gorm:"column:dod"`
+	DODDate *time.Time `gorm:"column:dod_date;type:date"`
+	POB     string     `gorm:"column:pob"`
+	POD     string     `gorm:"column:pod"`
+
+	POBPlace Place `gorm:"embedded;embeddedPrefix:pob_"`
+	PODPlace Place `gorm:"embedded;embeddedPrefix:pod_"`
+
+	AgeAtDisappearance *int `gorm:"column:age_at_disappearance"`
+	// by the age at disappearance, or by the age at normalization if it is not known, see Filter
+	Minor   bool `gorm:"column:minor"`
+	Elderly bool `gorm:"column:elderly"`
+
+	FirstSeen time.Time  `gorm:"column:first_seen"`
+	RemovedAt *time.Time `gorm:"column:removed_at"`
+
+	Data      datatypes.JSON `gorm:"type:jsonb"`
+	UpdatedAt time.Time      `gorm:"column:updated_at"`
+	// the versions of the country parser that produced the raw row and of the normalization (normalize.Version)
+	ParserVersion     int `gorm:"column:parser_version"`
+	NormalizerVersion int `gorm:"column:normalizer_version"`
+}
+
+func (Person) TableName() string {
+	return Persons_Table
+}
+
+/*
+*
+A geocoded place of birth or disappearance. Coordinates are nil if the place could not be found.
+*/
+type Place struct {
+	Latitude     *float64 `gorm:"column:latitude" json:"latitude"`
+	Longitude    *float64 `gorm:"column:longitude" json:"longitude"`
+	Municipality string   `gorm:"column:municipality" json:"municipality"`
+	County       string   `gorm:"column:county" json:"county"`
+	CountryCode  string   `gorm:"column:country_code" json:"country_code"`
+	GeonameID    int      `gorm:"column:geoname_id" json:"geoname_id"`
+	Confidence   float64  `gorm:"column:confidence" json:"confidence"`
+}
+
+func NewPlace(l geocode.Location) Place {
+	if !l.Found() {
+		return Place{}
+	}
+
+	return Place{
+		Latitude:     &l.Latitude,
+		Longitude:    &l.Longitude,
+		Municipality: l.Municipality,
+		County:       l.County,
+		CountryCode:  l.CountryCode,
+		GeonameID:    l.GeonameID,
+		Confidence:   l.Confidence,
+	}
+}
+
+/*
+*
+The scrapped data of a single raw row as the country package understands it.
+*/
+type Record struct {
+	RawID     int
+	ItemID    string
+	SourceURL string
+	FirstSeen time.Time
+	RemovedAt *time.Time
+	ImageHash string
+	// nil if the image has no perceptual hash yet
+	ImagePHash *int64
+	// version of the parser that produced the raw row
+	ParserVersion int
+	Person        normalize.Person
+}
+
+func NewPerson(country string, r Record, now time.Time) Person {
+	data, _ := json.Marshal(r.Person)
+	ages := normalize.NewAges(r.Person.DOB, r.Person.DOD, now)
+
+	return Person{
+		Country:            country,
+		RawID:              r.RawID,
+		ItemID:             r.ItemID,
+		SourceURL:          r.SourceURL,
+		Name:               r.Person.Name,
+		LastName:           r.Person.LastName,
+		Gender:             string(r.Person.Gender.Value),
+		DOB:                r.Person.DOB.Value,
+		DOBDate:            dateColumn(r.Person.DOB),
+		DOD:                r.Person.DOD.Value,
+		DODDate:            dateColumn(r.Person.DOD),
+		POB:                r.Person.POB,
+		POD:                r.Person.POD,
+		ImageHash:          r.ImageHash,
+		ImagePHash:         r.ImagePHash,
+		AgeAtDisappearance: ages.AgeAtDisappearance,
+		Minor:              ages.Minor,
+		Elderly:            ages.Elderly,
+		FirstSeen:          r.FirstSeen,
+		RemovedAt:          r.RemovedAt,
+		Data:               data,
+		UpdatedAt:          now,
+		ParserVersion:      r.ParserVersion,
+		NormalizerVersion:  normalize.Version,
+	}
+}
+
+// Normalized returns the stored normalize.Person.
+func (p Person) Normalized() normalize.Person {
+	var n normalize.Person
+	_ = json.Unmarshal(p.Data, &n)
+
+	return n
+}
+
+func (p Person) Ages(now time.Time) normalize.Ages {
+	n := p.Normalized()
+	return normalize.NewAges(n.DOB, n.DOD, now)
+}
+
+func dateColumn(d normalize.Date) *time.Time {
+	t, ok := d.Time()
+	if !ok {
+		return nil
+	}
+
+	return &t
+}
@@ -0,0 +1,167 @@
+package persons
+
+import (
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"missing-persons-scrapper/pkg/geocode"
+	"missing-persons-scrapper/pkg/normalize"
+	"time"
+)
+
+type Geocoder interface {
+	Geocode(text, country string) geocode.Location
+}
+
+// A country whose raw rows can be normalized.
+type Source interface {
+	Country() string
+	Records() ([]Record, error)
+}
+
+/*
+*
+Normalizes every raw row of the sources into the persons table of db and geocodes the places of birth and
+disappearance. Rows that already exist are overwritten, so this can run after every scrape.
+*/
+func Normalize(db *gorm.DB, geocoder Geocoder, sources ...Source) error {
+	now := time.Now()
+
+	for _, src := range sources {
+		persons, err := normalizeSource(geocoder, src, now)
+		if err != nil {
+			return err
+		}
+
+		if err := save(db, src.Country(), persons); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// What Reprocess did with the persons of a country.
+type Reprocessed struct {
+	Country    string
+	Normalized int
+	// persons whose normalized values are different than before
+	Changed int
+	// raw rows that were not normalized before
+	Created int
+}
+
+/*
+*
+Returns the number of persons normalized by another version of the normalization than normalize.Version,
+Reprocess normalizes them again.
+*/
+func Outdated(db *gorm.DB) (int64, error) {
+	var count int64
+	res := db.Model(&Person{}).Where("normalizer_version <> ?", normalize.Version).Count(&count)
+
+	return count, res.Error
+}
+
+/*
+*
+Normalizes every raw row of the sources again, like Normalize, and counts the persons whose normalized values
+changed. Only the values the normalization produces are compared, a person is not changed because it was
+normalized on another day or by another version.
+*/
+func Reprocess(db *gorm.DB, geocoder Geocoder, sources ...Source) ([]Reprocessed, error) {
+	now := time.Now()
+	result := make([]Reprocessed, 0, len(sources))
+
+	for _, src := range sources {
+		var existing []Person
+		res := db.Select("raw_id", "data", "age_at_disappearance", "pob_latitude", "pob_longitude", "pob_municipality",
+			"pob_county", "pob_country_code", "pob_geoname_id", "pob_confidence", "pod_latitude", "pod_longitude",
+			"pod_municipality", "pod_county", "pod_country_code", "pod_geoname_id", "pod_confidence").
+			Where("country = ?", src.Country()).Find(&existing)
+		if res.Error != nil {
+			return nil, fmt.Errorf("failed reading persons of %s: %w", src.Country(), res.Error)
+		}
+
+		before := make(map[int]string, len(existing))
+		for _, p := range existing {
+			before[p.RawID] = p.fingerprint()
+		}
+
+		persons, err := normalizeSource(geocoder, src, now)
+		if err != nil {
+			return nil, err
+		}
+
+		r := Reprocessed{Country: src.Country(), Normalized: len(persons)}
+		for _, p := range persons {
+			previous, ok := before[p.RawID]
+			switch {
+			case !ok:
+				r.Created++
+			case previous != p.fingerprint():
+				r.Changed++
+			}
+		}
+
+		if err := save(db, src.Country(), persons); err != nil {
+			return nil, err
+		}
+
+		result = append(result, r)
+	}
+
+	return result, nil
+}
+
+func normalizeSource(geocoder Geocoder, src Source, now time.Time) ([]Person, error) {
+	records, err := src.Records()
+	if err != nil {
+		return nil, fmt.Errorf("failed reading raw data of %s: %w", src.Country(), err)
+	}
+
+	persons := make([]Person, len(records))
+	for i, r := range records {
+		persons[i] = NewPerson(src.Country(), r, now)
+
+		if r.Person.POB != "" {
+			persons[i].POBPlace = NewPlace(geocoder.Geocode(r.Person.POB, src.Country()))
+		}
+
+		if r.Person.POD != "" {
+			persons[i].PODPlace = NewPlace(geocoder.Geocode(r.Person.POD, src.Country()))
+		}
+	}
+
+	return persons, nil
+}
+
+func save(db *gorm.DB, country string, persons []Person) error {
+	if len(persons) == 0 {
+		return nil
+	}
+
+	res := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "country"}, {Name: "raw_id"}},
+		UpdateAll: true,
+	}).CreateInBatches(&persons, 500)
+
+	if res.Error != nil {
+		return fmt.Errorf("failed saving persons of %s: %w", country, res.Error)
+	}
+
+	return nil
+}
+
+// the values the normalization produced, every other column is derived from them or copied from the raw row
+func (p Person) fingerprint() string {
+	b, _ := json.Marshal(struct {
+		Person             normalize.Person
+		POB                Place
+		POD                Place
+		AgeAtDisappearance *int
+	}{p.Normalized(), p.POBPlace, p.PODPlace, p.AgeAtDisappearance})
+
+	return string(b)
+}
@@ -0,0 +1,65 @@
+package persons
+
+import (
+	"github.com/stretchr/testify/assert"
+	"missing-persons-scrapper/pkg/geocode"
+	"missing-persons-scrapper/pkg/migrations"
+	"missing-persons-scrapper/pkg/normalize"
+	"missing-persons-scrapper/pkg/storage"
+	"testing"
+)
+
+type fakeSource []Record
+
+func (f fakeSource) Country() string {
+	return "hr"
+}
+
+func (f fakeSource) Records() ([]Record, error) {
+	return f, nil
+}
+
+type noGeocoder struct{}
+
+func (noGeocoder) Geocode(text, country string) geocode.Location {
+	return geocode.Location{}
+}
+
+func TestReprocess(t *testing.T) {
+	db, err := storage.Open(storage.Config{Driver: storage.DriverSQLite, Path: ":memory:"})
+	assert.Nil(t, err)
+	_, err = migrations.Up(db)
+	assert.Nil(t, err)
+
+	source := fakeSource{
+		{RawID: 1, ItemID: "7", ParserVersion: 1, Person: normalize.Person{Name: "Marko"}},
+		{RawID: 2, ItemID: "8", ParserVersion: 1, Person: normalize.Person{Name: "Ana"}},
+	}
+	assert.Nil(t, Normalize(db, noGeocoder{}, source))
+
+	found, err := FindByItem(db, "hr", "7")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, found.ParserVersion)
+	assert.Equal(t, normalize.Version, found.NormalizerVersion)
+
+	outdated, err := Outdated(db)
+	assert.Nil(t, err)
+	assert.Zero(t, outdated)
+
+	// normalized by an older version, which normalized the first name differently
+	assert.Nil(t, db.Model(&Person{}).Where("raw_id = 2").Update("normalizer_version", 0).Error)
+	source[1].Person.Name = "ANA"
+	source = append(source, Record{RawID: 3, ItemID: "9", Person: normalize.Person{Name: "Ivan"}})
+
+	outdated, err = Outdated(db)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), outdated)
+
+	result, err := Reprocess(db, noGeocoder{}, source)
+	assert.Nil(t, err)
+	assert.Equal(t, []Reprocessed{{Country: "hr", Normalized: 3, Changed: 1, Created: 1}}, result)
+
+	outdated, err = Outdated(db)
+	assert.Nil(t, err)
+	assert.Zero(t, outdated)
+}
@@ -0,0 +1,131 @@
+package persons
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"missing-persons-scrapper/pkg/normalize"
+	"time"
+)
+
+const (
+	// still on the official site
+	StatusActive = "active"
+	// removed from the official site, most likely found
+	StatusRemoved = "removed"
+)
+
+/*
+*
+Filters for reading persons, every person is read once with its newest version. Zero values do not filter. MinAge and MaxAge are the current age,
+DisappearedFrom and DisappearedTo are inclusive.
+*/
+type Filter struct {
+	Country         string
+	Status          string
+	Minor           *bool
+	Elderly         *bool
+	MinAge          *int
+	MaxAge          *int
+	DisappearedFrom *time.Time
+	DisappearedTo   *time.Time
+	// only persons whose place of disappearance was geocoded
+	Located bool
+	Limit   int
+	Offset  int
+}
+
+func Find(db *gorm.DB, f Filter) ([]Person, error) {
+	var persons []Person
+	res := f.apply(db.Model(&Person{}), time.Now()).Order("id").Find(&persons)
+
+	return persons, res.Error
+}
+
+// Returns the newest normalized row of the person with the website id itemID, gorm.ErrRecordNotFound if there is none.
+func FindByItem(db *gorm.DB, country, itemID string) (Person, error) {
+	var p Person
+	res := db.Where("country = ? AND item_id = ?", country, itemID).Order("raw_id DESC").First(&p)
+
+	return p, res.Error
+}
+
+/*
+*
+The current age is filtered on the date of birth, so it is always up to date without storing it.
+*/
+func (f Filter) apply(tx *gorm.DB, now time.Time) *gorm.DB {
+	tx = newest(tx)
+
+	if f.Country != "" {
+		tx = tx.Where("country = ?", f.Country)
+	}
+
+	if f.Minor != nil {
+		tx = ageGroup(tx, "minor", *f.Minor, "dob_date > ?", now.AddDate(-normalize.MinorAge, 0, 0))
+	}
+
+	if f.Elderly != nil {
+		tx = ageGroup(tx, "elderly", *f.Elderly, "dob_date <= ?", now.AddDate(-normalize.ElderlyAge, 0, 0))
+	}
+
+	if f.MinAge != nil {
+		tx = tx.Where("dob_date <= ?", now.AddDate(-*f.MinAge, 0, 0))
+	}
+
+	if f.MaxAge != nil {
+		tx = tx.Where("dob_date > ?", now.AddDate(-*f.MaxAge-1, 0, 0))
+	}
+
+	// a run marks the versions it did not see as removed, the person is removed only when every version is
+	onSite := fmt.Sprintf("EXISTS (SELECT 1 FROM %s o WHERE o.country = %s.country AND o.item_id = %s.item_id AND o.removed_at IS NULL)",
+		Persons_Table, Persons_Table, Persons_Table)
+	switch f.Status {
+	case StatusActive:
+		tx = tx.Where(onSite)
+	case StatusRemoved:
+		tx = tx.Where("NOT " + onSite)
+	}
+
+	if f.DisappearedFrom != nil {
+		tx = tx.Where("dod_date >= ?", *f.DisappearedFrom)
+	}
+
+	if f.DisappearedTo != nil {
+		tx = tx.Where("dod_date <= ?", *f.DisappearedTo)
+	}
+
+	if f.Located {
+		tx = tx.Where("pod_latitude IS NOT NULL AND pod_longitude IS NOT NULL")
+	}
+
+	if f.Limit > 0 {
+		tx = tx.Limit(f.Limit)
+	}
+
+	if f.Offset > 0 {
+		tx = tx.Offset(f.Offset)
+	}
+
+	return tx
+}
+
+// A person has a row for every version of its raw data, the newest one is the one with the highest raw id.
+func newest(tx *gorm.DB) *gorm.DB {
+	return tx.Where(fmt.Sprintf("raw_id = (SELECT MAX(o.raw_id) FROM %s o WHERE o.country = %s.country AND o.item_id = %s.item_id)",
+		Persons_Table, Persons_Table, Persons_Table))
+}
+
+/*
+*
+Filters on the minor or elderly column, which was computed from the age at disappearance if it is known. Without
+it the current age decides, so the date of birth is compared with now as the export computes it: byDOB is the
+condition of the persons in the group and bound the date it compares with.
+*/
+func ageGroup(tx *gorm.DB, column string, in bool, byDOB string, bound time.Time) *gorm.DB {
+	current := byDOB
+	if !in {
+		current = fmt.Sprintf("(dob_date IS NULL OR NOT (%s))", byDOB)
+	}
+
+	return tx.Where(fmt.Sprintf("((age_at_disappearance IS NOT NULL AND %s = ?) OR (age_at_disappearance IS NULL AND %s))", column, current), in, bound)
+}
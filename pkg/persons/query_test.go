@@ -0,0 +1,106 @@
+package persons
+
+import (
+	"github.com/stretchr/testify/assert"
+	"missing-persons-scrapper/pkg/migrations"
+	"missing-persons-scrapper/pkg/normalize"
+	"missing-persons-scrapper/pkg/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterMinor(t *testing.T) {
+	db, err := storage.Open(storage.Config{Driver: storage.DriverSQLite, Path: ":memory:"})
+	assert.Nil(t, err)
+	_, err = migrations.Up(db)
+	assert.Nil(t, err)
+
+	date := func(t time.Time) normalize.Date {
+		return normalize.ParseDate(t.Format("02.01.2006."))
+	}
+
+	now := time.Now()
+	source := fakeSource{
+		// turned 18 since the normalization, the date of disappearance is not known
+		{RawID: 1, ItemID: "7", Person: normalize.Person{Name: "Marko", DOB: date(now.AddDate(-18, 0, -10))}},
+		// was 10 when missing
+		{RawID: 2, ItemID: "8", Person: normalize.Person{Name: "Ana", DOB: date(now.AddDate(-30, 0, 0)), DOD: date(now.AddDate(-20, 0, 0))}},
+		{RawID: 3, ItemID: "9", Person: normalize.Person{Name: "Ivan"}},
+	}
+	assert.Nil(t, Normalize(db, noGeocoder{}, source))
+	assert.Nil(t, db.Model(&Person{}).Where("raw_id = 1").Update("minor", true).Error)
+
+	minor, adult := true, false
+	found, err := Find(db, Filter{Minor: &minor})
+	assert.Nil(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, "Ana", found[0].Name)
+
+	found, err = Find(db, Filter{Minor: &adult})
+	assert.Nil(t, err)
+	assert.Len(t, found, 2)
+	assert.Equal(t, "Marko", found[0].Name)
+	assert.False(t, found[0].Ages(now).Minor)
+}
+
+func TestFindNewestVersion(t *testing.T) {
+	db, err := storage.Open(storage.Config{Driver: storage.DriverSQLite, Path: ":memory:"})
+	assert.Nil(t, err)
+	_, err = migrations.Up(db)
+	assert.Nil(t, err)
+
+	// the page of the first person changed, it has a row for both versions
+	source := fakeSource{
+		{RawID: 1, ItemID: "7", Person: normalize.Person{Name: "Marko"}},
+		{RawID: 2, ItemID: "8", Person: normalize.Person{Name: "Ana"}},
+		{RawID: 3, ItemID: "7", Person: normalize.Person{Name: "Mark"}},
+	}
+	assert.Nil(t, Normalize(db, noGeocoder{}, source))
+
+	found, err := Find(db, Filter{})
+	assert.Nil(t, err)
+	assert.Len(t, found, 2)
+	assert.Equal(t, "Ana", found[0].Name)
+	assert.Equal(t, "Mark", found[1].Name)
+
+	found, err = Find(db, Filter{Country: "hr", Limit: 1})
+	assert.Nil(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, "Ana", found[0].Name)
+}
+
+func TestFilterStatus(t *testing.T) {
+	db, err := storage.Open(storage.Config{Driver: storage.DriverSQLite, Path: ":memory:"})
+	assert.Nil(t, err)
+	_, err = migrations.Up(db)
+	assert.Nil(t, err)
+
+	latitude, longitude := 45.8, 15.9
+	removedAt := time.Now()
+	// the old version of the first person is removed although the person is still on the site
+	source := fakeSource{
+		{RawID: 1, ItemID: "7", RemovedAt: &removedAt, Person: normalize.Person{Name: "Marko"}},
+		{RawID: 2, ItemID: "8", RemovedAt: &removedAt, Person: normalize.Person{Name: "Ana"}},
+		{RawID: 3, ItemID: "7", Person: normalize.Person{Name: "Mark"}},
+	}
+	assert.Nil(t, Normalize(db, noGeocoder{}, source))
+	assert.Nil(t, db.Model(&Person{}).Where("1 = 1").Updates(map[string]any{"pod_latitude": latitude, "pod_longitude": longitude, "pod_county": "Grad Zagreb", "pod_country_code": "HR"}).Error)
+
+	found, err := Find(db, Filter{Status: StatusRemoved})
+	assert.Nil(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, "Ana", found[0].Name)
+
+	found, err = Find(db, Filter{Status: StatusActive})
+	assert.Nil(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, "Mark", found[0].Name)
+
+	// every person is counted once in the county
+	found, err = Find(db, Filter{Located: true})
+	assert.Nil(t, err)
+	var b strings.Builder
+	assert.Nil(t, WriteCountiesGeoJSON(&b, found, time.Now()))
+	assert.Contains(t, b.String(), `"count":2`)
+}
@@ -0,0 +1,139 @@
+package persons
+
+import (
+	"database/sql"
+	"fmt"
+	"gorm.io/gorm"
+	"missing-persons-scrapper/pkg/imagefile"
+	"sync"
+)
+
+// A person whose photo is similar to a searched photo.
+type SimilarPhoto struct {
+	Person Person
+	// the number of different bits of the perceptual hashes, 0 is the same photo
+	Distance int
+}
+
+/*
+*
+The perceptual hashes of the current photos of the persons of a database, by person id. It is built once and
+built again when the persons change, after normalize or images phash, so a search does not read every person.
+*/
+type photoIndex struct {
+	mu sync.Mutex
+	// the number of persons with a photo and the time one of them was updated last when the index was built
+	version string
+	index   *imagefile.HashIndex
+}
+
+// the index of every database, the sessions of a database share its *gorm.Config
+var photos = struct {
+	mu      sync.Mutex
+	indexes map[*gorm.Config]*photoIndex
+}{indexes: make(map[*gorm.Config]*photoIndex)}
+
+func photosOf(db *gorm.DB) *photoIndex {
+	photos.mu.Lock()
+	defer photos.mu.Unlock()
+
+	x, ok := photos.indexes[db.Config]
+	if !ok {
+		x = &photoIndex{}
+		photos.indexes[db.Config] = x
+	}
+
+	return x
+}
+
+/*
+*
+Returns the persons whose current photo has a perceptual hash that differs in at most maxDistance bits
+from hash, the most similar first. A person that changed on the website is returned once, with its newest
+raw row. A limit of 0 returns every similar photo.
+*/
+func FindSimilarPhotos(db *gorm.DB, hash uint64, maxDistance, limit int) ([]SimilarPhoto, error) {
+	index, err := photosOf(db).current(db)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := index.Search(hash, maxDistance)
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	ids := make([]int, len(matches))
+	for i, m := range matches {
+		ids[i] = m.ID
+	}
+
+	var found []Person
+	if res := db.Where("id IN ?", ids).Find(&found); res.Error != nil {
+		return nil, res.Error
+	}
+
+	byID := make(map[int]Person, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+
+	similar := make([]SimilarPhoto, 0, len(matches))
+	for _, m := range matches {
+		// removed since the index was built
+		if p, ok := byID[m.ID]; ok {
+			similar = append(similar, SimilarPhoto{Person: p, Distance: m.Distance})
+		}
+	}
+
+	return similar, nil
+}
+
+// Returns the index of the persons as they are now, built again if they changed.
+func (x *photoIndex) current(db *gorm.DB) (*imagefile.HashIndex, error) {
+	version, err := photosVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.index != nil && x.version == version {
+		return x.index, nil
+	}
+
+	var rows []Person
+	res := db.Select("id", "country", "item_id", "image_phash").Where("image_phash IS NOT NULL").Order("raw_id DESC").Find(&rows)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	index := imagefile.NewHashIndex()
+	seen := make(map[string]bool)
+	for _, p := range rows {
+		key := p.Country + ":" + p.ItemID
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		index.Add(uint64(*p.ImagePHash), p.ID)
+	}
+
+	x.index, x.version = index, version
+
+	return index, nil
+}
+
+// changes when a person with a photo is added, updated or deleted
+func photosVersion(db *gorm.DB) (string, error) {
+	var count int64
+	var updated sql.NullString
+	row := db.Model(&Person{}).Where("image_phash IS NOT NULL").Select("COUNT(*), MAX(updated_at)").Row()
+	if err := row.Scan(&count, &updated); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d:%s", count, updated.String), nil
+}
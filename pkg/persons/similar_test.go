@@ -0,0 +1,42 @@
+package persons
+
+import (
+	"github.com/stretchr/testify/assert"
+	"missing-persons-scrapper/pkg/migrations"
+	"missing-persons-scrapper/pkg/normalize"
+	"missing-persons-scrapper/pkg/storage"
+	"testing"
+)
+
+func phash(hash int64) *int64 {
+	return &hash
+}
+
+func TestFindSimilarPhotos(t *testing.T) {
+	db, err := storage.Open(storage.Config{Driver: storage.DriverSQLite, Path: ":memory:"})
+	assert.Nil(t, err)
+	_, err = migrations.Up(db)
+	assert.Nil(t, err)
+
+	source := fakeSource{
+		{RawID: 1, ItemID: "7", ImagePHash: phash(0x0f), Person: normalize.Person{Name: "Marko"}},
+		{RawID: 2, ItemID: "8", ImagePHash: phash(0x0e), Person: normalize.Person{Name: "Ana"}},
+		{RawID: 3, ItemID: "9", Person: normalize.Person{Name: "Ivan"}},
+	}
+	assert.Nil(t, Normalize(db, noGeocoder{}, source))
+
+	found, err := FindSimilarPhotos(db, 0x0f, 2, 0)
+	assert.Nil(t, err)
+	assert.Len(t, found, 2)
+	assert.Equal(t, "Marko", found[0].Person.Name)
+	assert.Equal(t, 1, found[1].Distance)
+
+	// the index is built again after the persons were normalized again
+	source = append(source, Record{RawID: 4, ItemID: "10", ImagePHash: phash(0x0f), Person: normalize.Person{Name: "Josip"}})
+	assert.Nil(t, Normalize(db, noGeocoder{}, source))
+
+	found, err = FindSimilarPhotos(db, 0x0f, 0, 1)
+	assert.Nil(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, 3, photosOf(db).index.Len())
+}
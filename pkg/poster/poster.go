@@ -0,0 +1,252 @@
+package poster
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"io"
+	"missing-persons-scrapper/pkg/imagefile"
+	"missing-persons-scrapper/pkg/normalize"
+	"missing-persons-scrapper/pkg/persons"
+	"rsc.io/qr"
+	"strings"
+	"time"
+)
+
+// A4 in millimetres
+const (
+	pageWidth  = 210.0
+	pageHeight = 297.0
+	margin     = 12.0
+	// the largest photo, the photo keeps its aspect ratio inside
+	photoWidth  = 90.0
+	photoHeight = 110.0
+	qrSize      = 38.0
+	// photos are scaled down to this many pixels, more does not print any better at this size
+	photoPixels = 1000
+)
+
+// What a poster shows.
+type Poster struct {
+	Person persons.Person
+	// the current image of the person in any supported format, nil if the person has no image
+	Photo    []byte
+	Template Template
+	// ages are computed at this time
+	Now time.Time
+}
+
+/*
+*
+Writes a one page A4 PDF poster: the photo, the name, ages, the date and place of disappearance, the
+physical description, who to contact and a QR code with the link to the official page. Fonts are embedded,
+so the Croatian and Romanian letters print on any printer.
+*/
+func Render(w io.Writer, p Poster) error {
+	t := p.Template
+	person := p.Person.Normalized()
+	ages := p.Person.Ages(p.Now)
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(fmt.Sprintf("%s: %s", t.Title, fullName(person)), true)
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(false, margin)
+	pdf.AddUTF8FontFromBytes("go", "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes("go", "B", gobold.TTF)
+	pdf.AddPage()
+
+	// title banner
+	pdf.SetFillColor(200, 16, 46)
+	pdf.Rect(0, 0, pageWidth, 32, "F")
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("go", "B", 36)
+	pdf.SetXY(margin, 8)
+	pdf.CellFormat(pageWidth-2*margin, 16, t.Title, "", 0, "C", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+
+	if err := drawPhoto(pdf, p.Photo, t, 42); err != nil {
+		return err
+	}
+
+	// name
+	pdf.SetXY(margin, 42+photoHeight+6)
+	pdf.SetFont("go", "B", 26)
+	pdf.MultiCell(pageWidth-2*margin, 11, strings.ToUpper(fullName(person)), "", "C", false)
+	pdf.Ln(3)
+
+	// facts
+	facts := [][2]string{
+		{t.Age, age(ages.AgeAtDisappearance, t)},
+		{t.CurrentAge, age(ages.CurrentAge, t)},
+		{t.DOB, date(person.DOB, t)},
+		{t.Disappeared, date(person.DOD, t)},
+		{t.Place, person.POD},
+		{t.Height, measurement(person.Height)},
+		{t.Weight, measurement(person.Weight)},
+		{t.Hair, colour(person.Hair, t)},
+		{t.Eyes, colour(person.Eyes, t)},
+		{t.Marks, person.DistinguishingMarks},
+		{t.Clothing, person.Clothing},
+		{t.Description, person.Description},
+	}
+
+	// the QR code and the contact are at the bottom, the facts stop above them
+	bottom := pageHeight - margin - qrSize - 6
+	for _, f := range facts {
+		if strings.TrimSpace(f[1]) == "" || pdf.GetY() > bottom-7 {
+			continue
+		}
+
+		pdf.SetX(margin)
+		pdf.SetFont("go", "B", 12)
+		pdf.CellFormat(55, 7, f[0]+":", "", 0, "L", false, 0, "")
+		pdf.SetFont("go", "", 12)
+		pdf.MultiCell(pageWidth-2*margin-55, 7, truncate(f[1], 300), "", "L", false)
+	}
+
+	// contact
+	top := pageHeight - margin - qrSize
+	pdf.SetDrawColor(200, 16, 46)
+	pdf.SetLineWidth(0.8)
+	pdf.Line(margin, top-4, pageWidth-margin, top-4)
+
+	textWidth := pageWidth - 2*margin - qrSize - 6
+	pdf.SetXY(margin, top)
+	pdf.SetFont("go", "B", 14)
+	phone := policePhones[p.Person.Country]
+	if phone == "" {
+		phone = "112"
+	}
+	pdf.MultiCell(textWidth, 7, fmt.Sprintf(t.Contact, phone), "", "L", false)
+
+	pdf.SetX(margin)
+	pdf.SetFont("go", "", 9)
+	pdf.MultiCell(textWidth, 5, fmt.Sprintf("%s: %s", t.Source, p.Person.SourceURL), "", "L", false)
+
+	if err := drawQR(pdf, p.Person.SourceURL, pageWidth-margin-qrSize, top, qrSize); err != nil {
+		return err
+	}
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+
+	return pdf.Output(w)
+}
+
+// The photo is centered horizontally at y, a grey box is drawn if there is no photo or it cannot be read.
+func drawPhoto(pdf *gofpdf.Fpdf, photo []byte, t Template, y float64) error {
+	x := (pageWidth - photoWidth) / 2
+
+	var file imagefile.File
+	var err error
+	if len(photo) > 0 {
+		file, err = imagefile.Thumbnail(photo, photoPixels)
+	}
+
+	if len(photo) == 0 || err != nil {
+		pdf.SetFillColor(230, 230, 230)
+		pdf.Rect(x, y, photoWidth, photoHeight, "F")
+		pdf.SetXY(x, y+photoHeight/2-5)
+		pdf.SetFont("go", "", 14)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(photoWidth, 10, t.NoPhoto, "", 0, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		return nil
+	}
+
+	w, h := photoWidth, photoWidth*float64(file.Height)/float64(file.Width)
+	if h > photoHeight {
+		w, h = photoHeight*float64(file.Width)/float64(file.Height), photoHeight
+	}
+
+	pdf.RegisterImageOptionsReader("photo", gofpdf.ImageOptions{ImageType: "JPG"}, bytes.NewReader(file.Data))
+	pdf.ImageOptions("photo", (pageWidth-w)/2, y+(photoHeight-h)/2, w, h, false, gofpdf.ImageOptions{ImageType: "JPG"}, 0, "")
+
+	return pdf.Error()
+}
+
+// Draws the QR code of text as black squares, it stays sharp at any print size.
+func drawQR(pdf *gofpdf.Fpdf, text string, x, y, size float64) error {
+	if text == "" {
+		return nil
+	}
+
+	code, err := qr.Encode(text, qr.M)
+	if err != nil {
+		return fmt.Errorf("failed encoding the QR code: %w", err)
+	}
+
+	// a quiet zone of 2 modules around the code
+	module := size / float64(code.Size+4)
+	pdf.SetFillColor(0, 0, 0)
+	for row := 0; row < code.Size; row++ {
+		for col := 0; col < code.Size; col++ {
+			if code.Black(col, row) {
+				pdf.Rect(x+float64(col+2)*module, y+float64(row+2)*module, module, module, "F")
+			}
+		}
+	}
+
+	return nil
+}
+
+func fullName(p normalize.Person) string {
+	return strings.TrimSpace(p.Name + " " + p.LastName)
+}
+
+func age(years *int, t Template) string {
+	if years == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%d %s", *years, t.Years)
+}
+
+func date(d normalize.Date, t Template) string {
+	if !d.Valid {
+		return d.Original
+	}
+
+	tm, _ := d.Time()
+	switch d.Precision {
+	case normalize.PrecisionDay:
+		return tm.Format(t.DateLayout)
+	case normalize.PrecisionMonth:
+		return tm.Format("01/2006")
+	default:
+		return tm.Format("2006")
+	}
+}
+
+func measurement(m normalize.Measurement) string {
+	if !m.Valid {
+		return m.Original
+	}
+
+	if m.Min != m.Max {
+		return fmt.Sprintf("%d-%d %s", m.Min, m.Max, m.Unit)
+	}
+
+	return fmt.Sprintf("%d %s", m.Min, m.Unit)
+}
+
+func colour(c normalize.ColourValue, t Template) string {
+	if name, ok := t.Colours[c.Value]; ok {
+		return name
+	}
+
+	return c.Original
+}
+
+// long descriptions would push the contact off the page
+func truncate(s string, runes int) string {
+	r := []rune(s)
+	if len(r) <= runes {
+		return s
+	}
+
+	return string(r[:runes]) + "…"
+}
@@ -0,0 +1,61 @@
+package poster
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/png"
+	"missing-persons-scrapper/pkg/normalize"
+	"missing-persons-scrapper/pkg/persons"
+	"testing"
+	"time"
+)
+
+func testPerson(t *testing.T) persons.Person {
+	n := normalize.Person{
+		Name:     "Ana",
+		LastName: "Kovačić",
+		DOB:      normalize.ParseDate("12.03.1987."),
+		DOD:      normalize.ParseDate("01.02.2024."),
+		POD:      "Đakovo",
+		Physical: normalize.Physical{Height: normalize.ParseHeight("170 cm"), Hair: normalize.ParseHairColour("smeđa")},
+	}
+	data, err := json.Marshal(n)
+	assert.Nil(t, err)
+
+	return persons.Person{Country: "hr", ItemID: "7", SourceURL: "https://nestali.gov.hr/nestale-osobe-403/403?osoba_id=7", Data: data}
+}
+
+func TestRender(t *testing.T) {
+	photo := &bytes.Buffer{}
+	assert.Nil(t, png.Encode(photo, image.NewGray(image.Rect(0, 0, 30, 40))))
+
+	for _, language := range []string{LanguageCroatian, LanguageRomanian, LanguageEnglish} {
+		template, ok := TemplateFor(language)
+		assert.True(t, ok)
+
+		buff := &bytes.Buffer{}
+		err := Render(buff, Poster{Person: testPerson(t), Photo: photo.Bytes(), Template: template, Now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)})
+		assert.Nil(t, err)
+		assert.True(t, bytes.HasPrefix(buff.Bytes(), []byte("%PDF-")))
+		assert.Equal(t, 1, bytes.Count(buff.Bytes(), []byte("/Type /Page\n")))
+	}
+
+	// a person without a photo
+	buff := &bytes.Buffer{}
+	assert.Nil(t, Render(buff, Poster{Person: testPerson(t), Template: templates[LanguageEnglish], Now: time.Now()}))
+}
+
+func TestFormatting(t *testing.T) {
+	hr := templates[LanguageCroatian]
+	assert.Equal(t, "12.03.1987.", date(normalize.ParseDate("12.03.1987."), hr))
+	assert.Equal(t, "1987", date(normalize.ParseDate("1987."), hr))
+	assert.Equal(t, "12 March 1987", date(normalize.ParseDate("12.03.1987."), templates[LanguageEnglish]))
+	assert.Equal(t, "smeđa", colour(normalize.ParseHairColour("smeđa"), hr))
+	assert.Equal(t, "castaniu", colour(normalize.ParseHairColour("smeđa"), templates[LanguageRomanian]))
+	assert.Equal(t, "170-180 cm", measurement(normalize.ParseHeight("170-180 cm")))
+
+	assert.Equal(t, LanguageRomanian, DefaultLanguage("ro"))
+	assert.Equal(t, LanguageEnglish, DefaultLanguage("de"))
+}
@@ -0,0 +1,140 @@
+package poster
+
+import "missing-persons-scrapper/pkg/normalize"
+
+const (
+	LanguageCroatian = "hr"
+	LanguageRomanian = "ro"
+	LanguageEnglish  = "en"
+)
+
+// The texts of a poster in one language.
+type Template struct {
+	Language    string
+	Title       string
+	NoPhoto     string
+	Age         string
+	CurrentAge  string
+	DOB         string
+	Disappeared string
+	Place       string
+	Height      string
+	Weight      string
+	Hair        string
+	Eyes        string
+	Marks       string
+	Clothing    string
+	Description string
+	// the police phone number and the official page are appended
+	Contact string
+	Source  string
+	Years   string
+	Colours map[normalize.Colour]string
+	// day precision date layout, months and years are written as numbers
+	DateLayout string
+}
+
+var templates = map[string]Template{
+	LanguageCroatian: {
+		Language:    LanguageCroatian,
+		Title:       "NESTALA OSOBA",
+		NoPhoto:     "Nema fotografije",
+		Age:         "Dob pri nestanku",
+		CurrentAge:  "Sadašnja dob",
+		DOB:         "Datum rođenja",
+		Disappeared: "Datum nestanka",
+		Place:       "Mjesto nestanka",
+		Height:      "Visina",
+		Weight:      "Težina",
+		Hair:        "Kosa",
+		Eyes:        "Oči",
+		Marks:       "Posebni znakovi",
+		Clothing:    "Odjeća",
+		Description: "Opis",
+		Contact:     "Ako imate bilo kakvu informaciju, javite se policiji na broj %s.",
+		Source:      "Službena stranica",
+		Years:       "god.",
+		Colours: map[normalize.Colour]string{
+			normalize.ColourBlack: "crna", normalize.ColourBrown: "smeđa", normalize.ColourBlonde: "plava",
+			normalize.ColourRed: "riđa", normalize.ColourGrey: "sijeda", normalize.ColourWhite: "bijela",
+			normalize.ColourBald: "ćelav", normalize.ColourBlue: "plave", normalize.ColourGreen: "zelene",
+			normalize.ColourHazel: "lješnjak",
+		},
+		DateLayout: "02.01.2006.",
+	},
+	LanguageRomanian: {
+		Language:    LanguageRomanian,
+		Title:       "PERSOANĂ DISPĂRUTĂ",
+		NoPhoto:     "Fără fotografie",
+		Age:         "Vârsta la dispariție",
+		CurrentAge:  "Vârsta actuală",
+		DOB:         "Data nașterii",
+		Disappeared: "Data dispariției",
+		Place:       "Locul dispariției",
+		Height:      "Înălțime",
+		Weight:      "Greutate",
+		Hair:        "Păr",
+		Eyes:        "Ochi",
+		Marks:       "Semne particulare",
+		Clothing:    "Îmbrăcăminte",
+		Description: "Descriere",
+		Contact:     "Dacă aveți orice informație, sunați la poliție la numărul %s.",
+		Source:      "Pagina oficială",
+		Years:       "ani",
+		Colours: map[normalize.Colour]string{
+			normalize.ColourBlack: "negru", normalize.ColourBrown: "castaniu", normalize.ColourBlonde: "blond",
+			normalize.ColourRed: "roșcat", normalize.ColourGrey: "cărunt", normalize.ColourWhite: "alb",
+			normalize.ColourBald: "chel", normalize.ColourBlue: "albaștri", normalize.ColourGreen: "verzi",
+			normalize.ColourHazel: "alunii",
+		},
+		DateLayout: "02.01.2006",
+	},
+	LanguageEnglish: {
+		Language:    LanguageEnglish,
+		Title:       "MISSING PERSON",
+		NoPhoto:     "No photo",
+		Age:         "Age when missing",
+		CurrentAge:  "Current age",
+		DOB:         "Date of birth",
+		Disappeared: "Missing since",
+		Place:       "Last seen in",
+		Height:      "Height",
+		Weight:      "Weight",
+		Hair:        "Hair",
+		Eyes:        "Eyes",
+		Marks:       "Distinguishing marks",
+		Clothing:    "Clothing",
+		Description: "Description",
+		Contact:     "If you have any information, call the police at %s.",
+		Source:      "Official page",
+		Years:       "years",
+		Colours: map[normalize.Colour]string{
+			normalize.ColourBlack: "black", normalize.ColourBrown: "brown", normalize.ColourBlonde: "blonde",
+			normalize.ColourRed: "red", normalize.ColourGrey: "grey", normalize.ColourWhite: "white",
+			normalize.ColourBald: "bald", normalize.ColourBlue: "blue", normalize.ColourGreen: "green",
+			normalize.ColourHazel: "hazel",
+		},
+		DateLayout: "2 January 2006",
+	},
+}
+
+// the police number of every country, the persons are reported to the police
+var policePhones = map[string]string{
+	"hr": "192",
+	"ro": "112",
+}
+
+// Returns the template of the language, ok is false if there is no template for it.
+func TemplateFor(language string) (Template, bool) {
+	t, ok := templates[language]
+	return t, ok
+}
+
+// The language of the country's site, English for other countries.
+func DefaultLanguage(country string) string {
+	if _, ok := templates[country]; ok {
+		return country
+	}
+
+	return LanguageEnglish
+}
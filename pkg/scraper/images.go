@@ -0,0 +1,93 @@
+package scraper
+
+import (
+	"fmt"
+	"missing-persons-scrapper/pkg/htmlParser"
+	"missing-persons-scrapper/pkg/imagefile"
+	"missing-persons-scrapper/pkg/storage"
+)
+
+// Why a person has no image.
+const (
+	ImageNotOnPage = "no image on the page"
+)
+
+/*
+*
+An image of a saved person that is downloaded after the persons of the run are saved. An image with a
+Reason is not downloaded, the reason is recorded as the reason the person has no image.
+*/
+type PendingImage struct {
+	// id of the raw row
+	RawID  int
+	URL    string
+	Reason string
+}
+
+/*
+*
+Downloads the images and writes them in batches, so no transaction is open while an image server is slow
+or retrying. Only valid images are saved, without their metadata. An image that is the same as the saved one is not written again and an image that changed is
+saved as a new version, the previous versions are kept.
+
+An image that could not be downloaded is picked up by the next run, it does not make the run incomplete. A
+person that has a saved image keeps it, the reason is only recorded for a person without one.
+Returns the number of images that were not saved. Parsing pages again keeps the saved images.
+*/
+func DownloadImages(deps Dependencies, writer *storage.Writer, images []PendingImage) int {
+	if deps.Replay {
+		return 0
+	}
+
+	checkedAt := deps.Clock()
+	missing := make(map[int]string)
+
+	failed := 0
+	for _, img := range images {
+		if img.Reason != "" {
+			missing[img.RawID] = img.Reason
+			continue
+		}
+
+		blob, err := htmlParser.GetBody(deps.Fetcher, img.URL)
+		if err != nil {
+			deps.Logger.Println(fmt.Errorf("failed downloading image: %s: %w", img.URL, err))
+			missing[img.RawID] = fmt.Sprintf("download failed: %s", err.Error())
+			failed++
+			continue
+		}
+
+		// the format is detected from the content, the url and the Content-Type are not trusted
+		file, err := imagefile.Normalize(blob, deps.ImageFormat)
+		if err != nil {
+			deps.Logger.Println(fmt.Errorf("invalid image: %s: %w", img.URL, err))
+			missing[img.RawID] = err.Error()
+			failed++
+			continue
+		}
+
+		image := storage.NewImage(img.RawID, file, checkedAt)
+		if _, err := deps.Images.Put(image.Blob, image.MimeType); err != nil {
+			deps.Logger.Println(fmt.Errorf("failed storing image: %s: %w", img.URL, err))
+			missing[img.RawID] = fmt.Sprintf("storing failed: %s", err.Error())
+			failed++
+			continue
+		}
+
+		// the database only keeps the description of the image
+		image.Blob = nil
+		if err := writer.AddImage(image); err != nil {
+			deps.Logger.Println(err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		deps.Logger.Println(err)
+	}
+
+	if err := deps.Store.MarkImagesMissing(missing); err != nil {
+		deps.Logger.Println(fmt.Errorf("failed recording missing images: %w", err))
+	}
+
+	return failed + writer.FailedImages
+}
@@ -0,0 +1,171 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"missing-persons-scrapper/pkg/htmlParser"
+	"missing-persons-scrapper/pkg/storage"
+	"sort"
+	"strings"
+)
+
+// the share of the checked person pages of a run that may not meet the expectations
+const DefaultMaxFailures = 0.1
+
+// person pages checked before the share of the failed ones can stop a run, so a few bad pages at the start do not
+const MinCheckedPages = 20
+
+var ErrLayoutChanged = errors.New("layout changed")
+
+/*
+*
+What the pages of a site are expected to look like. A site that changes its layout does not return errors, the
+selectors match nothing and every person would be saved as an empty row with the same unique identifier.
+*/
+type Expectations struct {
+	// selectors that match on every person page
+	Selectors []string `yaml:"selectors"`
+	// the least number of tokens of a person page
+	MinTokens int `yaml:"min_tokens"`
+	// labels that are a token of every person page
+	Labels []string `yaml:"labels"`
+	// the least number of persons the list pages of a complete run link to, a run that lists no one always fails
+	MinListed int `yaml:"min_listed"`
+	// the share of the checked person pages that may fail, DefaultMaxFailures if 0
+	MaxFailures float64 `yaml:"max_failures"`
+}
+
+/*
+*
+Checks the pages of a run against the Expectations. A person page that does not meet them is not saved. When
+the share of the failed pages is beyond the threshold the layout is taken as changed: the run stops, what is not
+written yet is discarded, no person is marked as removed and the run is recorded with a layout alert.
+*/
+type Layout struct {
+	expectations Expectations
+	// person pages checked and the ones that did not meet the expectations
+	Checked int
+	Failed  int
+	// persons the list pages linked to
+	Listed int
+	// the number of pages by unmet expectation
+	unmet map[string]int
+}
+
+func NewLayout(expectations Expectations) *Layout {
+	if expectations.MaxFailures == 0 {
+		expectations.MaxFailures = DefaultMaxFailures
+	}
+
+	return &Layout{expectations: expectations, unmet: make(map[string]int)}
+}
+
+// Returns an error wrapping ErrLayoutChanged with the expectations the person page does not meet.
+func (l *Layout) CheckPage(doc *html.Node, tokens []string) error {
+	l.Checked++
+
+	unmet := make([]string, 0)
+	for _, sel := range l.expectations.Selectors {
+		if n, err := htmlParser.Find(doc, sel); err != nil || n == nil {
+			unmet = append(unmet, fmt.Sprintf("no %s", sel))
+		}
+	}
+
+	if len(tokens) < l.expectations.MinTokens {
+		unmet = append(unmet, fmt.Sprintf("fewer than %d tokens", l.expectations.MinTokens))
+	}
+
+	for _, label := range l.expectations.Labels {
+		if !hasToken(tokens, label) {
+			unmet = append(unmet, fmt.Sprintf("no label %s", label))
+		}
+	}
+
+	if len(unmet) == 0 {
+		return nil
+	}
+
+	l.Failed++
+	for _, u := range unmet {
+		l.unmet[u]++
+	}
+
+	return fmt.Errorf("%w: %s", ErrLayoutChanged, strings.Join(unmet, ", "))
+}
+
+// Counts the persons a list page links to.
+func (l *Layout) List(persons int) {
+	l.Listed += persons
+}
+
+// Reports whether enough person pages failed to stop the run.
+func (l *Layout) Changed() bool {
+	return l.Checked >= MinCheckedPages && l.tooManyFailed()
+}
+
+/*
+*
+Checks the whole run, the share of the failed person pages of every checked page and, if the run went through
+every list page, the number of listed persons. If the layout changed the alert of the run is set and the failed
+pages are counted as failed persons. Returns whether the layout changed, the rows that are not written yet
+should then be discarded.
+*/
+func (l *Layout) Finish(run *storage.Run, complete bool) bool {
+	run.Failed += l.Failed
+
+	alerts := make([]string, 0)
+	if l.Checked > 0 && l.tooManyFailed() {
+		alerts = append(alerts, fmt.Sprintf("%d of %d person pages failed: %s", l.Failed, l.Checked, l.summary()))
+	}
+
+	// the list selectors stopped matching, the run would mark every person as removed
+	if complete && l.Listed == 0 {
+		alerts = append(alerts, "the list pages linked to no persons")
+	} else if complete && l.Listed < l.expectations.MinListed {
+		alerts = append(alerts, fmt.Sprintf("the list pages linked to %d persons, expected at least %d", l.Listed, l.expectations.MinListed))
+	}
+
+	if len(alerts) == 0 {
+		return false
+	}
+
+	run.LayoutChanged = true
+	run.LayoutAlert = strings.Join(alerts, "; ")
+
+	return true
+}
+
+func (l *Layout) tooManyFailed() bool {
+	return float64(l.Failed) > l.expectations.MaxFailures*float64(l.Checked)
+}
+
+// the unmet expectations with the number of pages, the most common first
+func (l *Layout) summary() string {
+	unmet := make([]string, 0, len(l.unmet))
+	for u := range l.unmet {
+		unmet = append(unmet, u)
+	}
+	sort.Slice(unmet, func(i, j int) bool {
+		if l.unmet[unmet[i]] != l.unmet[unmet[j]] {
+			return l.unmet[unmet[i]] > l.unmet[unmet[j]]
+		}
+		return unmet[i] < unmet[j]
+	})
+
+	for i, u := range unmet {
+		unmet[i] = fmt.Sprintf("%s (%d)", u, l.unmet[u])
+	}
+
+	return strings.Join(unmet, ", ")
+}
+
+func hasToken(tokens []string, token string) bool {
+	for _, t := range tokens {
+		if strings.TrimSpace(t) == token {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,49 @@
+package scraper
+
+import (
+	"missing-persons-scrapper/pkg/httpClient"
+	"missing-persons-scrapper/pkg/storage"
+	"time"
+)
+
+/*
+*
+Returns Dependencies that read the pages with fetcher, a replay of an archive (warc.NewReplayFetcher) or of
+the HTTP cache (httpClient.NewReplayFetcher), so a scraper parses the saved pages again with its current
+parser without requesting anything from the official sites.
+
+The newest row of every person is updated in place with the new data and parser version. Nothing else is
+written: the images, first_seen and last_seen stay, no person is marked as removed, the run is not recorded
+and persons that have no row are skipped, the next scrape adds them.
+*/
+func (d Dependencies) Reparse(fetcher httpClient.Fetcher) Dependencies {
+	d.Fetcher = fetcher
+	d.Store = &reparseStore{Store: d.Store}
+	d.Archive = nil
+	d.Replay = true
+
+	return d
+}
+
+// a Store that only updates the parsed data of the rows
+type reparseStore struct {
+	storage.Store
+}
+
+func (s *reparseStore) UpsertRaws(raws []storage.Raw) ([]storage.Outcome, error) {
+	return s.Store.ReparseRaws(raws)
+}
+
+func (s *reparseStore) MarkRemoved(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (s *reparseStore) RecordRun(run *storage.Run) error {
+	return nil
+}
+
+func (s *reparseStore) Transaction(fn func(store storage.Store) error) error {
+	return s.Store.Transaction(func(store storage.Store) error {
+		return fn(&reparseStore{Store: store})
+	})
+}
@@ -0,0 +1,182 @@
+package scraper
+
+import (
+	"fmt"
+	"log"
+	"missing-persons-scrapper/pkg/httpClient"
+	"missing-persons-scrapper/pkg/imagefile"
+	"missing-persons-scrapper/pkg/imagestore"
+	"missing-persons-scrapper/pkg/storage"
+	"missing-persons-scrapper/pkg/warc"
+	"os"
+	"strconv"
+	"time"
+)
+
+type Clock func() time.Time
+
+/*
+*
+Everything a country scraper reads from and writes to. The scrapers use nothing else, so two scrapers with
+different dependencies can run in one process and a scraper can be tested with a fake Fetcher.
+*/
+type Dependencies struct {
+	Fetcher httpClient.Fetcher
+	// the store of the country's tables
+	Store storage.Store
+	// where the image files are kept
+	Images imagestore.Store
+	Clock  Clock
+	Logger *log.Logger
+	// number of persons and images written in a single statement
+	BatchSize int
+	// the format every image is re-encoded to, nil keeps the format of the image
+	ImageFormat *imagefile.Format
+	// where the fetched pages are archived, nil if they are not
+	Archive *warc.Archive
+	// the pages are read from an archive and parsed again, see Reparse
+	Replay bool
+}
+
+/*
+*
+Returns Dependencies that fetch the official sites, use the real clock and log to the standard logger. The
+batch size is read from WRITE_BATCH_SIZE and the format images are re-encoded to from IMAGE_FORMAT (jpeg or
+png, empty keeps the format).
+
+Responses are cached in HTTP_CACHE_DIR (./http-cache by default), so pages that did not change since the last
+run are not parsed and written again. HTTP_CACHE_DIR=off fetches every page in full. Pages are archived if
+ARCHIVE_DIR is set, see warc.FromEnv.
+*/
+func NewDependencies(store storage.Store, images imagestore.Store) (Dependencies, error) {
+	batchSize, err := strconv.Atoi(os.Getenv("WRITE_BATCH_SIZE"))
+	if err != nil || batchSize < 1 {
+		batchSize = storage.DefaultBatchSize
+	}
+
+	var format *imagefile.Format
+	if name := os.Getenv("IMAGE_FORMAT"); name != "" {
+		f, err := imagefile.FormatByName(name)
+		if err != nil {
+			return Dependencies{}, err
+		}
+
+		format = &f
+	}
+
+	fetcher := httpClient.NewFetcher(httpClient.ClientParams{})
+	if dir := os.Getenv("HTTP_CACHE_DIR"); dir != "off" {
+		if dir == "" {
+			dir = "http-cache"
+		}
+
+		cache, err := httpClient.NewCache(dir)
+		if err != nil {
+			return Dependencies{}, err
+		}
+
+		fetcher = httpClient.NewCachedFetcher(httpClient.ClientParams{}, cache)
+	}
+
+	archive, err := warc.FromEnv()
+	if err != nil {
+		return Dependencies{}, err
+	}
+
+	return Dependencies{
+		Fetcher:     fetcher,
+		Store:       store,
+		Images:      images,
+		Clock:       time.Now,
+		Logger:      log.Default(),
+		BatchSize:   batchSize,
+		ImageFormat: format,
+		Archive:     archive,
+	}, nil
+}
+
+/*
+*
+Records the run as started, so it has an id, and archives every page fetched until the returned function is
+called, in files of the run. The function finishes the archive.
+*/
+func (d *Dependencies) BeginRun(run *storage.Run) func() {
+	if err := d.Store.RecordRun(run); err != nil {
+		d.Logger.Println(fmt.Errorf("failed recording the start of the run: %w", err))
+	}
+
+	if d.Archive == nil {
+		return func() {}
+	}
+
+	writer, err := d.Archive.Open(run.Country, run.ID, run.StartedAt)
+	if err != nil {
+		d.Logger.Println(fmt.Errorf("failed opening the archive, pages are not archived: %w", err))
+		return func() {}
+	}
+
+	fetcher := d.Fetcher
+	d.Fetcher = warc.NewFetcher(fetcher, writer)
+
+	return func() {
+		d.Fetcher = fetcher
+		if err := writer.Close(); err != nil {
+			d.Logger.Println(fmt.Errorf("failed closing the archive: %w", err))
+		}
+	}
+}
+
+/*
+*
+Ends a run the same way for every scraper: writes what is left unless the layout changed, downloads the images
+of the written persons, counts what the writer did, marks the persons that are not on the site any more as
+removed if the run was complete and records the run. The writer adds the images of the persons it writes last
+to images. Returns the recorded run.
+*/
+func (d Dependencies) FinishRun(run storage.Run, writer *storage.Writer, layout *Layout, images *[]PendingImage, complete bool) storage.Run {
+	if layout.Finish(&run, complete) {
+		d.Logger.Printf("%s: layout changed, %d persons were not written: %s\n", run.Country, writer.Discard(), run.LayoutAlert)
+		complete = false
+	} else if err := writer.Flush(); err != nil {
+		d.Logger.Println(err)
+		complete = false
+	}
+
+	if failed := DownloadImages(d, writer, *images); failed > 0 {
+		d.Logger.Printf("%s: %d images were not saved\n", run.Country, failed)
+	}
+
+	run.Failed += writer.Failed
+	run.Created = writer.Counts[storage.OutcomeCreated]
+	run.Updated = writer.Counts[storage.OutcomeUpdated]
+	run.Unchanged = writer.Counts[storage.OutcomeUnchanged]
+	run.Complete = complete
+	if complete {
+		removed, err := d.Store.MarkRemoved(run.StartedAt)
+		if err != nil {
+			d.Logger.Println(fmt.Errorf("failed marking removed persons: %w", err))
+		}
+		run.Removed = removed
+	} else {
+		d.Logger.Printf("%s: run was not complete, skipping marking removed persons\n", run.Country)
+	}
+
+	finishedAt := d.Clock()
+	run.FinishedAt = &finishedAt
+	if err := d.Store.RecordRun(&run); err != nil {
+		d.Logger.Println(fmt.Errorf("failed recording the run: %w", err))
+	}
+
+	return run
+}
+
+// Returns a Writer of the store with the batch size.
+func (d Dependencies) Writer() *storage.Writer {
+	return storage.NewWriter(d.Store, d.BatchSize)
+}
+
+type Scraper interface {
+	Country() string
+	// scrapes every person of the country and records the run
+	Run() storage.Run
+}
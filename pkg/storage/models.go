@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"gorm.io/datatypes"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"missing-persons-scrapper/pkg/imagefile"
+	"net/http"
+	"time"
+)
+
+/*
+*
+The raw scrapped data of a person. Every country stores it in its own table with these columns.
+*/
+type Raw struct {
+	ID               int
+	Data             datatypes.JSON `gorm:"type:jsonb"`
+	ItemID           string         `gorm:"column:item_id"`
+	UniqueIdentifier string         `gorm:"column:unique_identifier;type:text"`
+	SourceURL        string         `gorm:"column:source_url"`
+	// version of the parser that produced Data, 0 for rows parsed before versions were recorded
+	ParserVersion int `gorm:"column:parser_version"`
+	// first and last run that saw this person on the official site
+	FirstSeen time.Time `gorm:"column:first_seen"`
+	LastSeen  time.Time `gorm:"column:last_seen"`
+	// set when a complete run no longer finds the person, most likely because the person was found
+	RemovedAt *time.Time `gorm:"column:removed_at"`
+	// why the person has no image, empty if the image was saved
+	MissingImageReason string `gorm:"column:missing_image_reason"`
+}
+
+/*
+*
+A version of the image of a person, ItemID is the id of the raw row (not the website id). A person has a
+version for every different image the site showed, the current one is the one checked last.
+
+The image file is kept in the image store under its hash. Blob is only set for images saved before the image
+store existed that were not moved yet.
+*/
+type Image struct {
+	ID        int    `gorm:"column:id"`
+	ItemID    int    `gorm:"column:item_id"`
+	Extension string `gorm:"column:extension"`
+	Blob      []byte `gorm:"column:blob"`
+	// sha256 of the image, the key of the image store
+	Hash     string `gorm:"column:hash"`
+	Size     int    `gorm:"column:size"`
+	MimeType string `gorm:"column:mime_type"`
+	// 0 if the image could not be decoded
+	Width  int `gorm:"column:width"`
+	Height int `gorm:"column:height"`
+	// perceptual hash of the image (imagefile.PerceptualHash) stored as a signed integer, nil until computed
+	PHash *int64 `gorm:"column:phash"`
+	// first and last time this version was downloaded
+	FetchedAt time.Time `gorm:"column:fetched_at"`
+	CheckedAt time.Time `gorm:"column:checked_at"`
+}
+
+// Returns the validated image file and its description. The blob is cleared once it is in the image store.
+func NewImage(itemID int, file imagefile.File, fetchedAt time.Time) Image {
+	return Image{
+		ItemID:    itemID,
+		Extension: file.Format.Extension,
+		Blob:      file.Data,
+		Hash:      fmt.Sprintf("%x", sha256.Sum256(file.Data)),
+		Size:      len(file.Data),
+		MimeType:  file.Format.MimeType,
+		Width:     file.Width,
+		Height:    file.Height,
+		PHash:     PHash(file.PHash),
+		FetchedAt: fetchedAt,
+		CheckedAt: fetchedAt,
+	}
+}
+
+// sets the hash, size, MIME type and dimensions of a blob saved before images were validated
+func (img *Image) describe() {
+	img.Hash = fmt.Sprintf("%x", sha256.Sum256(img.Blob))
+	img.Size = len(img.Blob)
+	img.MimeType = http.DetectContentType(img.Blob)
+
+	if config, _, err := image.DecodeConfig(bytes.NewReader(img.Blob)); err == nil {
+		img.Width = config.Width
+		img.Height = config.Height
+	}
+
+	if hash, err := imagefile.HashData(img.Blob); err == nil {
+		img.PHash = PHash(hash)
+	}
+}
+
+// The perceptual hash as it is stored, the databases have no unsigned 64 bit integers.
+func PHash(hash uint64) *int64 {
+	stored := int64(hash)
+	return &stored
+}
+
+// The tables of a country.
+type Tables struct {
+	Raw    string
+	Images string
+}
+
+const Runs_Table = "scrape_runs"
+
+/*
+*
+A single scrape of a country. A run is complete if every list and person page was read, only then it can
+tell which persons are not on the official site anymore.
+*/
+type Run struct {
+	ID        int       `gorm:"column:id"`
+	Country   string    `gorm:"column:country;index"`
+	StartedAt time.Time `gorm:"column:started_at"`
+	// nil while the run is going on, or if it never finished
+	FinishedAt *time.Time `gorm:"column:finished_at"`
+	Complete   bool       `gorm:"column:complete"`
+	Seen       int        `gorm:"column:seen"`
+	Failed     int        `gorm:"column:failed"`
+	Removed    int64      `gorm:"column:removed"`
+	// outcomes of the saved persons
+	Created   int `gorm:"column:created"`
+	Updated   int `gorm:"column:updated"`
+	Unchanged int `gorm:"column:unchanged"`
+	// the pages of the site did not meet the expectations of the scraper, see scraper.Layout
+	LayoutChanged bool   `gorm:"column:layout_changed"`
+	LayoutAlert   string `gorm:"column:layout_alert"`
+}
+
+func (Run) TableName() string {
+	return Runs_Table
+}
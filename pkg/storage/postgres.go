@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"fmt"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func postgresDialector(config Config) gorm.Dialector {
+	dsn := fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Europe/Zagreb",
+		config.Host,
+		config.User,
+		config.Password,
+		config.Name,
+		config.Port,
+	)
+
+	return postgres.Open(dsn)
+}
@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"errors"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+/*
+*
+SQLite needs no database server, which makes it the database for running the scrapers and the tests
+locally. The driver is pure Go, so it works without cgo. Use ":memory:" as the path for a database that
+only lives as long as the program.
+*/
+func sqliteDialector(config Config) (gorm.Dialector, error) {
+	if config.Path == "" {
+		return nil, errors.New("DATABASE_PATH is required for sqlite")
+	}
+
+	// foreign keys are off by default and concurrent writers should wait rather than fail
+	return sqlite.Open(config.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"), nil
+}
@@ -2,52 +2,112 @@ package storage
 
 import (
 	"fmt"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
-	"log"
 	"os"
 )
 
-var DB *gorm.DB
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+/*
+*
+DATABASE_DRIVER selects the database, "postgres" (the default) or "sqlite". Postgres is configured with
+DATABASE_HOST, DATABASE_USER, DATABASE_PASSWORD, DATABASE_NAME and DATABASE_PORT, SQLite with DATABASE_PATH,
+the path of the database file.
+*/
+type Config struct {
+	Driver   string
+	Host     string
+	User     string
+	Password string
+	Name     string
+	Port     string
+	Path     string
+}
+
+func ConfigFromEnv() Config {
+	driver := os.Getenv("DATABASE_DRIVER")
+	if driver == "" {
+		driver = DriverPostgres
+	}
+
+	return Config{
+		Driver:   driver,
+		Host:     os.Getenv("DATABASE_HOST"),
+		User:     os.Getenv("DATABASE_USER"),
+		Password: os.Getenv("DATABASE_PASSWORD"),
+		Name:     os.Getenv("DATABASE_NAME"),
+		Port:     os.Getenv("DATABASE_PORT"),
+		Path:     os.Getenv("DATABASE_PATH"),
+	}
+}
+
+/*
+*
+Connects to the database configured in the environment. Nothing keeps the connection, the code that reads or
+writes the database is given it, so a program can use another database or two of them.
+*/
+func Connect() (*gorm.DB, error) {
+	db, err := Open(ConfigFromEnv())
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to database: %w", err)
+	}
+
+	return db, nil
+}
+
+func Open(config Config) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	var err error
+
+	switch config.Driver {
+	case DriverPostgres:
+		dialector = postgresDialector(config)
+	case DriverSQLite:
+		dialector, err = sqliteDialector(config)
+	default:
+		err = fmt.Errorf("unknown database driver %s", config.Driver)
+	}
 
-func Connect() {
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Europe/Zagreb",
-		os.Getenv("DATABASE_HOST"),
-		os.Getenv("DATABASE_USER"),
-		os.Getenv("DATABASE_PASSWORD"),
-		os.Getenv("DATABASE_NAME"),
-		os.Getenv("DATABASE_PORT"),
-	)
+	if err != nil {
+		return nil, err
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		SkipDefaultTransaction: true,
 		PrepareStmt:            true,
 		Logger:                 logger.Default.LogMode(logger.Silent),
 	})
 
 	if err != nil {
-		log.Fatalln(fmt.Sprintf("Cannot connect to database: %s", err.Error()))
+		return nil, err
 	}
 
 	rawDb, err := db.DB()
 	if err != nil {
-		log.Fatalln(fmt.Sprintf("Cannot connect to database: %s", err.Error()))
+		return nil, err
 	}
 
 	if err := rawDb.Ping(); err != nil {
-		log.Fatalln(fmt.Sprintf("Cannot connect to database: %s", err.Error()))
+		return nil, err
+	}
+
+	if config.Driver == DriverSQLite {
+		// SQLite allows a single writer, concurrent scrapers wait for each other instead of failing
+		rawDb.SetMaxOpenConns(1)
 	}
 
-	DB = db
+	return db, nil
 }
 
-func Close() {
-	handle, err := DB.DB()
+func Close(db *gorm.DB) error {
+	handle, err := db.DB()
 	if err != nil {
-		log.Fatalln("Calling close on an instance that is not open")
+		return fmt.Errorf("calling close on an instance that is not open: %w", err)
 	}
 
-	handle.Close()
+	return handle.Close()
 }
@@ -0,0 +1,521 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"missing-persons-scrapper/pkg/imagefile"
+	"time"
+)
+
+/*
+*
+The writes of a scraper. Every country has its own tables, so a Store is created for the tables of a
+country. Both Postgres and SQLite databases implement it through gorm.
+*/
+type Store interface {
+	FindRaw(id int) (Raw, error)
+	// returns gorm.ErrRecordNotFound if there is no such row
+	FindRawByIdentifier(uniqueIdentifier string) (Raw, error)
+	// inserts the row if it has no id, otherwise updates everything except first_seen
+	UpsertRaw(raw *Raw) error
+	// saves the image as UpsertImages does and sets its id
+	UpsertImage(image *Image) error
+	// inserts the rows or updates the rows with the same unique identifier in a single statement and sets
+	// their ids, updating keeps first_seen. Returns the outcome of every row.
+	UpsertRaws(raws []Raw) ([]Outcome, error)
+	// replaces the data, unique identifier and parser version of the newest row of every website id in place,
+	// for pages that are parsed again. The row keeps its id, first_seen, last_seen and images. A website id
+	// without a row is skipped.
+	ReparseRaws(raws []Raw) ([]Outcome, error)
+	// adds the images as new versions of the images of their rows, an image that is the same as the current
+	// version is only checked again. Returns the outcome of every image.
+	UpsertImages(images []Image) ([]Outcome, error)
+	// sets why the rows, by id, have no image. A row that has a saved image keeps it and gets no reason.
+	MarkImagesMissing(reasons map[int]string) error
+	// moves the blobs of the images saved before the image store existed with put, returns the number of moved images
+	MoveBlobs(put func(data []byte, contentType string) (string, error)) (int, error)
+	// computes the perceptual hash of the images in the image store that do not have one, returns the number
+	// of hashed images. Images that cannot be read or decoded are skipped.
+	HashImages(get func(key string) ([]byte, error)) (int, error)
+	// sets last_seen of the newest row of every website id and clears removed_at, used for persons whose page
+	// did not change. Returns the website ids that have no row.
+	MarkSeen(itemIDs []string, seenAt time.Time) ([]string, error)
+	// sets removed_at of every row that was not seen since before
+	MarkRemoved(before time.Time) (int64, error)
+	// inserts the run if it has no id and sets the id, otherwise updates it
+	RecordRun(run *Run) error
+	// runs fn in a transaction, the Store passed to fn writes in that transaction
+	Transaction(fn func(store Store) error) error
+}
+
+type Outcome string
+
+const (
+	OutcomeCreated   Outcome = "created"
+	OutcomeUpdated   Outcome = "updated"
+	OutcomeUnchanged Outcome = "unchanged"
+	// the row was not written, see ReparseRaws
+	OutcomeSkipped Outcome = "skipped"
+)
+
+type gormStore struct {
+	db     *gorm.DB
+	tables Tables
+}
+
+func NewStore(db *gorm.DB, tables Tables) Store {
+	return &gormStore{db: db, tables: tables}
+}
+
+func (s *gormStore) FindRaw(id int) (Raw, error) {
+	var raw Raw
+	res := s.db.Table(s.tables.Raw).Where("id = ?", id).First(&raw)
+
+	return raw, res.Error
+}
+
+func (s *gormStore) FindRawByIdentifier(uniqueIdentifier string) (Raw, error) {
+	var raw Raw
+	res := s.db.Table(s.tables.Raw).Where("unique_identifier = ?", uniqueIdentifier).First(&raw)
+
+	return raw, res.Error
+}
+
+func (s *gormStore) UpsertRaw(raw *Raw) error {
+	if raw.ID == 0 {
+		return s.db.Table(s.tables.Raw).Create(raw).Error
+	}
+
+	return s.db.Table(s.tables.Raw).Omit("first_seen").Save(raw).Error
+}
+
+func (s *gormStore) UpsertImage(image *Image) error {
+	if _, err := s.UpsertImages([]Image{*image}); err != nil {
+		return err
+	}
+
+	var saved Image
+	res := s.db.Table(s.tables.Images).Select("id").Where("item_id = ? AND hash = ?", image.ItemID, image.Hash).First(&saved)
+	image.ID = saved.ID
+
+	return res.Error
+}
+
+func (s *gormStore) UpsertRaws(raws []Raw) ([]Outcome, error) {
+	if len(raws) == 0 {
+		return nil, nil
+	}
+
+	identifiers := make([]string, 0, len(raws))
+	// a person listed twice in a batch is written once, the same row cannot be updated twice in a statement
+	unique := make(map[string]int)
+	rows := make([]Raw, 0, len(raws))
+	for _, r := range raws {
+		if i, ok := unique[r.UniqueIdentifier]; ok {
+			rows[i] = r
+			continue
+		}
+
+		unique[r.UniqueIdentifier] = len(rows)
+		identifiers = append(identifiers, r.UniqueIdentifier)
+		r.ID = 0
+		rows = append(rows, r)
+	}
+
+	var existing []Raw
+	res := s.db.Table(s.tables.Raw).
+		Select("id", "unique_identifier", "item_id", "source_url", "removed_at", "parser_version").
+		Where("unique_identifier IN ?", identifiers).
+		Find(&existing)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	current := make(map[string]Raw, len(existing))
+	for _, e := range existing {
+		current[e.UniqueIdentifier] = e
+	}
+
+	res = s.db.Table(s.tables.Raw).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "unique_identifier"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data", "item_id", "source_url", "last_seen", "removed_at", "parser_version"}),
+	}).Create(&rows)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	// ids of the created rows, the returned ids of an upsert are not reliable on every database
+	var saved []Raw
+	res = s.db.Table(s.tables.Raw).Select("id", "unique_identifier").Where("unique_identifier IN ?", identifiers).Find(&saved)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	ids := make(map[string]int, len(saved))
+	for _, r := range saved {
+		ids[r.UniqueIdentifier] = r.ID
+	}
+
+	outcomes := make([]Outcome, len(raws))
+	for i := range raws {
+		raws[i].ID = ids[raws[i].UniqueIdentifier]
+
+		c, ok := current[raws[i].UniqueIdentifier]
+		switch {
+		case !ok:
+			outcomes[i] = OutcomeCreated
+		case c.ItemID != raws[i].ItemID || c.SourceURL != raws[i].SourceURL || c.RemovedAt != nil || c.ParserVersion != raws[i].ParserVersion:
+			outcomes[i] = OutcomeUpdated
+		default:
+			outcomes[i] = OutcomeUnchanged
+		}
+	}
+
+	return outcomes, nil
+}
+
+func (s *gormStore) ReparseRaws(raws []Raw) ([]Outcome, error) {
+	if len(raws) == 0 {
+		return nil, nil
+	}
+
+	itemIDs := make([]string, 0, len(raws))
+	for _, r := range raws {
+		itemIDs = append(itemIDs, r.ItemID)
+	}
+
+	var existing []Raw
+	res := s.db.Table(s.tables.Raw).
+		Select("id", "unique_identifier", "item_id", "source_url", "parser_version").
+		Where("item_id IN ?", itemIDs).
+		Where(s.newest()).
+		Find(&existing)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	newest := make(map[string]Raw, len(existing))
+	for _, e := range existing {
+		newest[e.ItemID] = e
+	}
+
+	outcomes := make([]Outcome, len(raws))
+	for i := range raws {
+		c, ok := newest[raws[i].ItemID]
+		if !ok {
+			outcomes[i] = OutcomeSkipped
+			continue
+		}
+
+		raws[i].ID = c.ID
+		if c.UniqueIdentifier == raws[i].UniqueIdentifier && c.SourceURL == raws[i].SourceURL && c.ParserVersion == raws[i].ParserVersion {
+			outcomes[i] = OutcomeUnchanged
+			continue
+		}
+
+		// the new data can be the same as an older version of the person, the identifier has to stay unique
+		var duplicates int64
+		res := s.db.Table(s.tables.Raw).Where("unique_identifier = ? AND id <> ?", raws[i].UniqueIdentifier, c.ID).Count(&duplicates)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+
+		if duplicates > 0 {
+			log.Printf("skipped reparsing %s, an older version of the person has the same data\n", raws[i].ItemID)
+			outcomes[i] = OutcomeSkipped
+			continue
+		}
+
+		res = s.db.Table(s.tables.Raw).Where("id = ?", c.ID).Updates(map[string]interface{}{
+			"data":              raws[i].Data,
+			"unique_identifier": raws[i].UniqueIdentifier,
+			"source_url":        raws[i].SourceURL,
+			"parser_version":    raws[i].ParserVersion,
+		})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+
+		outcomes[i] = OutcomeUpdated
+	}
+
+	return outcomes, nil
+}
+
+// a person that changed on the website has a row for every version, the newest one is the current one
+func (s *gormStore) newest() string {
+	return fmt.Sprintf("last_seen = (SELECT MAX(o.last_seen) FROM %s o WHERE o.item_id = %s.item_id)", s.tables.Raw, s.tables.Raw)
+}
+
+func (s *gormStore) UpsertImages(images []Image) ([]Outcome, error) {
+	if len(images) == 0 {
+		return nil, nil
+	}
+
+	itemIDs := make([]int, 0, len(images))
+	unique := make(map[int]int)
+	rows := make([]Image, 0, len(images))
+	for _, img := range images {
+		if img.Hash == "" {
+			img.Hash = fmt.Sprintf("%x", sha256.Sum256(img.Blob))
+		}
+
+		if i, ok := unique[img.ItemID]; ok {
+			rows[i] = img
+			continue
+		}
+
+		unique[img.ItemID] = len(rows)
+		itemIDs = append(itemIDs, img.ItemID)
+		img.ID = 0
+		rows = append(rows, img)
+	}
+
+	// every version of the images without the blobs
+	var versions []Image
+	res := s.db.Table(s.tables.Images).
+		Select("id", "item_id", "hash", "checked_at").
+		Where("item_id IN ?", itemIDs).
+		Order("checked_at").Order("id").
+		Find(&versions)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	current := make(map[int]Image)
+	known := make(map[int]map[string]int)
+	for _, v := range versions {
+		current[v.ItemID] = v
+		if known[v.ItemID] == nil {
+			known[v.ItemID] = make(map[string]int)
+		}
+		known[v.ItemID][v.Hash] = v.ID
+	}
+
+	/**
+	An image that did not change is only checked again, its blob is not written. A version that was shown
+	before becomes the current version again, every other image is a new version.
+	*/
+	outcomes := make(map[int]Outcome, len(rows))
+	checked := make(map[time.Time][]int)
+	insert := make([]Image, 0, len(rows))
+	for _, r := range rows {
+		c, ok := current[r.ItemID]
+		switch {
+		case ok && c.Hash == r.Hash:
+			outcomes[r.ItemID] = OutcomeUnchanged
+			checked[r.CheckedAt] = append(checked[r.CheckedAt], c.ID)
+		case known[r.ItemID][r.Hash] != 0:
+			outcomes[r.ItemID] = OutcomeUpdated
+			checked[r.CheckedAt] = append(checked[r.CheckedAt], known[r.ItemID][r.Hash])
+		case ok:
+			outcomes[r.ItemID] = OutcomeUpdated
+			insert = append(insert, r)
+		default:
+			outcomes[r.ItemID] = OutcomeCreated
+			insert = append(insert, r)
+		}
+	}
+
+	for checkedAt, ids := range checked {
+		res := s.db.Table(s.tables.Images).Where("id IN ?", ids).Update("checked_at", checkedAt)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+	}
+
+	if len(insert) > 0 {
+		res = s.db.Table(s.tables.Images).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "item_id"}, {Name: "hash"}},
+			DoUpdates: clause.AssignmentColumns([]string{"checked_at"}),
+		}).Create(&insert)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+	}
+
+	// the persons have an image now
+	res = s.db.Table(s.tables.Raw).
+		Where("id IN ? AND missing_image_reason <> ''", itemIDs).
+		Update("missing_image_reason", "")
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	result := make([]Outcome, len(images))
+	for i, img := range images {
+		result[i] = outcomes[img.ItemID]
+	}
+
+	return result, nil
+}
+
+func (s *gormStore) MarkImagesMissing(reasons map[int]string) error {
+	byReason := make(map[string][]int)
+	for id, reason := range reasons {
+		byReason[reason] = append(byReason[reason], id)
+	}
+
+	for reason, ids := range byReason {
+		res := s.db.Table(s.tables.Raw).
+			Where("id IN ?", ids).
+			Where(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s i WHERE i.item_id = %s.id)", s.tables.Images, s.tables.Raw)).
+			Update("missing_image_reason", reason)
+		if res.Error != nil {
+			return res.Error
+		}
+	}
+
+	return nil
+}
+
+func (s *gormStore) MoveBlobs(put func(data []byte, contentType string) (string, error)) (int, error) {
+	moved := 0
+	for {
+		var batch []Image
+		res := s.db.Table(s.tables.Images).Where("blob IS NOT NULL").Order("id").Limit(50).Find(&batch)
+		if res.Error != nil {
+			return moved, res.Error
+		}
+
+		if len(batch) == 0 {
+			return moved, nil
+		}
+
+		for _, img := range batch {
+			hadHash := img.Hash != ""
+			img.describe()
+
+			if _, err := put(img.Blob, img.MimeType); err != nil {
+				return moved, fmt.Errorf("failed moving image %d: %w", img.ID, err)
+			}
+
+			// an image saved without a hash can be the same as a version saved later
+			if !hadHash {
+				var duplicates int64
+				res := s.db.Table(s.tables.Images).Where("item_id = ? AND hash = ?", img.ItemID, img.Hash).Count(&duplicates)
+				if res.Error != nil {
+					return moved, res.Error
+				}
+
+				if duplicates > 0 {
+					if res := s.db.Table(s.tables.Images).Where("id = ?", img.ID).Delete(&Image{}); res.Error != nil {
+						return moved, res.Error
+					}
+
+					moved++
+					continue
+				}
+			}
+
+			res := s.db.Table(s.tables.Images).Where("id = ?", img.ID).Updates(map[string]interface{}{
+				"blob":      nil,
+				"hash":      img.Hash,
+				"size":      img.Size,
+				"mime_type": img.MimeType,
+				"width":     img.Width,
+				"height":    img.Height,
+				"phash":     img.PHash,
+			})
+			if res.Error != nil {
+				return moved, res.Error
+			}
+
+			moved++
+		}
+	}
+}
+
+func (s *gormStore) HashImages(get func(key string) ([]byte, error)) (int, error) {
+	hashed := 0
+	lastID := 0
+	for {
+		var batch []Image
+		res := s.db.Table(s.tables.Images).Select("id", "hash").
+			Where("phash IS NULL AND hash IS NOT NULL AND blob IS NULL AND id > ?", lastID).
+			Order("id").Limit(50).Find(&batch)
+		if res.Error != nil {
+			return hashed, res.Error
+		}
+
+		if len(batch) == 0 {
+			return hashed, nil
+		}
+
+		for _, img := range batch {
+			lastID = img.ID
+
+			data, err := get(img.Hash)
+			if err != nil {
+				log.Println(fmt.Errorf("failed reading image %d: %w", img.ID, err))
+				continue
+			}
+
+			hash, err := imagefile.HashData(data)
+			if err != nil {
+				log.Println(fmt.Errorf("failed hashing image %d: %w", img.ID, err))
+				continue
+			}
+
+			if res := s.db.Table(s.tables.Images).Where("id = ?", img.ID).Update("phash", PHash(hash)); res.Error != nil {
+				return hashed, res.Error
+			}
+
+			hashed++
+		}
+	}
+}
+
+func (s *gormStore) MarkSeen(itemIDs []string, seenAt time.Time) ([]string, error) {
+	if len(itemIDs) == 0 {
+		return nil, nil
+	}
+
+	res := s.db.Table(s.tables.Raw).
+		Where("item_id IN ?", itemIDs).
+		Where(s.newest()).
+		Updates(map[string]interface{}{"last_seen": seenAt, "removed_at": nil})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	var found []string
+	if res := s.db.Table(s.tables.Raw).Where("item_id IN ?", itemIDs).Distinct().Pluck("item_id", &found); res.Error != nil {
+		return nil, res.Error
+	}
+
+	known := make(map[string]bool, len(found))
+	for _, id := range found {
+		known[id] = true
+	}
+
+	missing := make([]string, 0)
+	for _, id := range itemIDs {
+		if !known[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	return missing, nil
+}
+
+func (s *gormStore) MarkRemoved(before time.Time) (int64, error) {
+	res := s.db.Table(s.tables.Raw).
+		Where("last_seen < ? AND removed_at IS NULL", before).
+		Update("removed_at", time.Now())
+
+	return res.RowsAffected, res.Error
+}
+
+func (s *gormStore) RecordRun(run *Run) error {
+	return s.db.Save(run).Error
+}
+
+func (s *gormStore) Transaction(fn func(store Store) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&gormStore{db: tx, tables: s.tables})
+	})
+}
@@ -0,0 +1,327 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"image"
+	"image/png"
+	"missing-persons-scrapper/pkg/imagefile"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var testTables = Tables{Raw: "test_scrapped", Images: "test_images"}
+
+func testStore(t *testing.T) Store {
+	db, err := Open(Config{Driver: DriverSQLite, Path: ":memory:"})
+	assert.Nil(t, err)
+
+	assert.Nil(t, db.Table(testTables.Raw).AutoMigrate(&Raw{}))
+	assert.Nil(t, db.Table(testTables.Images).AutoMigrate(&Image{}))
+	assert.Nil(t, db.AutoMigrate(&Run{}))
+	// the unique indexes of the migrations, the batch upserts conflict on them
+	assert.Nil(t, db.Exec("CREATE UNIQUE INDEX test_scrapped_unique_identifier_key ON test_scrapped (unique_identifier)").Error)
+	assert.Nil(t, db.Exec("CREATE UNIQUE INDEX test_images_item_id_hash_key ON test_images (item_id, hash)").Error)
+
+	return NewStore(db, testTables)
+}
+
+func testImage(itemID int, blob []byte, fetchedAt time.Time) Image {
+	return NewImage(itemID, imagefile.File{Format: imagefile.JPEG, Data: blob}, fetchedAt)
+}
+
+func TestUpsertRaw(t *testing.T) {
+	store := testStore(t)
+
+	firstSeen := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	raw := Raw{Data: []byte(`["Ime:","Marko"]`), ItemID: "1", UniqueIdentifier: "abc", FirstSeen: firstSeen, LastSeen: firstSeen}
+	assert.Nil(t, store.UpsertRaw(&raw))
+	assert.NotZero(t, raw.ID)
+
+	// updating keeps the first time the person was seen
+	raw.FirstSeen = time.Now()
+	raw.LastSeen = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	assert.Nil(t, store.UpsertRaw(&raw))
+
+	found, err := store.FindRawByIdentifier("abc")
+	assert.Nil(t, err)
+	assert.Equal(t, raw.ID, found.ID)
+	assert.True(t, found.FirstSeen.Equal(firstSeen))
+	assert.True(t, found.LastSeen.Equal(raw.LastSeen))
+
+	_, err = store.FindRawByIdentifier("missing")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func TestImageVersions(t *testing.T) {
+	store := testStore(t)
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	img := testImage(1, []byte{1}, day)
+	assert.Nil(t, store.UpsertImage(&img))
+	first := img.ID
+
+	// the same image is only checked again
+	outcomes, err := store.UpsertImages([]Image{testImage(1, []byte{1}, day.Add(24*time.Hour))})
+	assert.Nil(t, err)
+	assert.Equal(t, []Outcome{OutcomeUnchanged}, outcomes)
+
+	// a new photo is a new version, the old one is kept
+	img = testImage(1, []byte{2}, day.Add(48*time.Hour))
+	assert.Nil(t, store.UpsertImage(&img))
+	assert.NotEqual(t, first, img.ID)
+
+	var versions []Image
+	assert.Nil(t, store.(*gormStore).db.Table(testTables.Images).Order("id").Find(&versions).Error)
+	assert.Len(t, versions, 2)
+	assert.True(t, versions[0].FetchedAt.Equal(day))
+	assert.True(t, versions[0].CheckedAt.Equal(day.Add(24*time.Hour)))
+	assert.Equal(t, []byte{1}, versions[0].Blob)
+
+	// the old photo is shown again
+	outcomes, err = store.UpsertImages([]Image{testImage(1, []byte{1}, day.Add(72*time.Hour))})
+	assert.Nil(t, err)
+	assert.Equal(t, []Outcome{OutcomeUpdated}, outcomes)
+}
+
+func TestMarkImagesMissing(t *testing.T) {
+	store := testStore(t)
+
+	raw := Raw{ItemID: "1", UniqueIdentifier: "a"}
+	assert.Nil(t, store.UpsertRaw(&raw))
+	assert.Nil(t, store.MarkImagesMissing(map[int]string{raw.ID: "no image on the page"}))
+
+	found, err := store.FindRaw(raw.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "no image on the page", found.MissingImageReason)
+
+	img := testImage(raw.ID, []byte{1}, time.Now())
+	assert.Nil(t, store.UpsertImage(&img))
+
+	found, err = store.FindRaw(raw.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "", found.MissingImageReason)
+
+	// the download of a new version failed, the person still has the saved image
+	assert.Nil(t, store.MarkImagesMissing(map[int]string{raw.ID: "download failed"}))
+	found, err = store.FindRaw(raw.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "", found.MissingImageReason)
+}
+
+func TestMarkRemovedInTransaction(t *testing.T) {
+	store := testStore(t)
+	runStart := time.Now()
+
+	old := Raw{ItemID: "1", UniqueIdentifier: "a", LastSeen: runStart.Add(-time.Hour)}
+	seen := Raw{ItemID: "2", UniqueIdentifier: "b", LastSeen: runStart.Add(time.Minute)}
+
+	err := store.Transaction(func(tx Store) error {
+		if err := tx.UpsertRaw(&old); err != nil {
+			return err
+		}
+
+		return tx.UpsertRaw(&seen)
+	})
+	assert.Nil(t, err)
+
+	removed, err := store.MarkRemoved(runStart)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), removed)
+
+	found, err := store.FindRaw(old.ID)
+	assert.Nil(t, err)
+	assert.NotNil(t, found.RemovedAt)
+
+	assert.Nil(t, store.RecordRun(&Run{Country: "hr", StartedAt: runStart, Complete: true, Removed: removed}))
+}
+
+func TestWriter(t *testing.T) {
+	store := testStore(t)
+	firstSeen := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	existing := Raw{ItemID: "1", UniqueIdentifier: "a", FirstSeen: firstSeen, LastSeen: firstSeen.Add(2 * time.Hour)}
+	assert.Nil(t, store.UpsertRaw(&existing))
+	removed := Raw{ItemID: "2", UniqueIdentifier: "b", FirstSeen: firstSeen, LastSeen: firstSeen}
+	assert.Nil(t, store.UpsertRaw(&removed))
+	_, err := store.MarkRemoved(firstSeen.Add(time.Hour))
+	assert.Nil(t, err)
+
+	seenAt := firstSeen.Add(24 * time.Hour)
+	outcomes := make(map[string]Outcome)
+	ids := make(map[string]int)
+	saved := func(raw Raw, outcome Outcome) {
+		outcomes[raw.UniqueIdentifier] = outcome
+		ids[raw.UniqueIdentifier] = raw.ID
+	}
+
+	writer := NewWriter(store, 2)
+	for i, uid := range []string{"a", "b", "c"} {
+		assert.Nil(t, writer.AddRaw(Raw{ItemID: strconv.Itoa(i + 1), UniqueIdentifier: uid, FirstSeen: seenAt, LastSeen: seenAt}, saved))
+	}
+
+	// the first two are written as soon as the batch is full
+	assert.Len(t, outcomes, 2)
+	assert.Nil(t, writer.Flush())
+
+	assert.Equal(t, OutcomeUnchanged, outcomes["a"])
+	assert.Equal(t, OutcomeUpdated, outcomes["b"])
+	assert.Equal(t, OutcomeCreated, outcomes["c"])
+	assert.Equal(t, existing.ID, ids["a"])
+	assert.NotZero(t, ids["c"])
+	assert.Equal(t, 1, writer.Counts[OutcomeCreated])
+
+	found, err := store.FindRaw(removed.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, found.RemovedAt)
+	assert.True(t, found.FirstSeen.Equal(firstSeen))
+	assert.True(t, found.LastSeen.Equal(seenAt))
+
+	assert.Nil(t, writer.AddImage(Image{ItemID: ids["a"], Extension: "jpg", Blob: []byte{1}}))
+	assert.Nil(t, writer.AddImage(Image{ItemID: ids["a"], Extension: "png", Blob: []byte{2}}))
+	assert.Nil(t, writer.Flush())
+
+	outcomesImages, err := store.UpsertImages([]Image{{ItemID: ids["a"], Extension: "gif", Blob: []byte{3}}})
+	assert.Nil(t, err)
+	assert.Equal(t, []Outcome{OutcomeUpdated}, outcomesImages)
+}
+
+func TestReparseRaws(t *testing.T) {
+	store := testStore(t)
+	firstSeen := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// two versions of the first person, the second is the newest
+	older := Raw{ItemID: "1", UniqueIdentifier: "a", FirstSeen: firstSeen, LastSeen: firstSeen}
+	assert.Nil(t, store.UpsertRaw(&older))
+	newest := Raw{ItemID: "1", UniqueIdentifier: "b", FirstSeen: firstSeen, LastSeen: firstSeen.Add(time.Hour)}
+	assert.Nil(t, store.UpsertRaw(&newest))
+	other := Raw{ItemID: "2", UniqueIdentifier: "c", ParserVersion: 2, FirstSeen: firstSeen, LastSeen: firstSeen}
+	assert.Nil(t, store.UpsertRaw(&other))
+
+	seenAt := firstSeen.Add(24 * time.Hour)
+	raws := []Raw{
+		{ItemID: "1", UniqueIdentifier: "d", Data: []byte(`["new"]`), ParserVersion: 2, LastSeen: seenAt},
+		{ItemID: "2", UniqueIdentifier: "c", ParserVersion: 2, LastSeen: seenAt},
+		{ItemID: "3", UniqueIdentifier: "e", ParserVersion: 2, LastSeen: seenAt},
+	}
+	outcomes, err := store.ReparseRaws(raws)
+	assert.Nil(t, err)
+	assert.Equal(t, []Outcome{OutcomeUpdated, OutcomeUnchanged, OutcomeSkipped}, outcomes)
+	assert.Equal(t, newest.ID, raws[0].ID)
+
+	found, err := store.FindRaw(newest.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "d", found.UniqueIdentifier)
+	assert.Equal(t, `["new"]`, string(found.Data))
+	assert.Equal(t, 2, found.ParserVersion)
+	assert.True(t, found.LastSeen.Equal(newest.LastSeen))
+
+	found, err = store.FindRaw(older.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", found.UniqueIdentifier)
+
+	_, err = store.FindRawByIdentifier("e")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestWriterSeen(t *testing.T) {
+	store := testStore(t)
+	firstSeen := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// the person changed on the website once, the newer version is the current one
+	old := Raw{ItemID: "1", UniqueIdentifier: "a", FirstSeen: firstSeen, LastSeen: firstSeen}
+	assert.Nil(t, store.UpsertRaw(&old))
+	current := Raw{ItemID: "1", UniqueIdentifier: "b", FirstSeen: firstSeen, LastSeen: firstSeen.Add(time.Hour)}
+	assert.Nil(t, store.UpsertRaw(&current))
+	_, err := store.MarkRemoved(firstSeen.Add(2 * time.Hour))
+	assert.Nil(t, err)
+
+	seenAt := firstSeen.Add(24 * time.Hour)
+	unknown := make([]string, 0)
+	writer := NewWriter(store, 10)
+	for _, id := range []string{"1", "2"} {
+		assert.Nil(t, writer.AddSeen(id, seenAt, func() error {
+			unknown = append(unknown, id)
+			return writer.AddRaw(Raw{ItemID: id, UniqueIdentifier: "c", FirstSeen: seenAt, LastSeen: seenAt}, nil)
+		}))
+	}
+	assert.Nil(t, writer.Flush())
+
+	assert.Equal(t, []string{"2"}, unknown)
+	assert.Equal(t, 1, writer.Counts[OutcomeUnchanged])
+	assert.Equal(t, 1, writer.Counts[OutcomeCreated])
+
+	found, err := store.FindRaw(current.ID)
+	assert.Nil(t, err)
+	assert.True(t, found.LastSeen.Equal(seenAt))
+	assert.Nil(t, found.RemovedAt)
+
+	found, err = store.FindRaw(old.ID)
+	assert.Nil(t, err)
+	assert.True(t, found.LastSeen.Equal(firstSeen))
+	assert.NotNil(t, found.RemovedAt)
+}
+
+func TestMoveBlobs(t *testing.T) {
+	store := testStore(t)
+	db := store.(*gormStore).db
+
+	// an image saved before images had hashes and one saved before the image store existed
+	assert.Nil(t, db.Exec("INSERT INTO test_images (item_id, extension, blob) VALUES (1, 'jpg', ?)", []byte{1}).Error)
+	img := testImage(2, []byte{2}, time.Now())
+	assert.Nil(t, store.UpsertImage(&img))
+
+	moved := make(map[string][]byte)
+	count, err := store.MoveBlobs(func(data []byte, _ string) (string, error) {
+		key := fmt.Sprintf("%x", sha256.Sum256(data))
+		moved[key] = data
+		return key, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+	assert.Len(t, moved, 2)
+
+	var images []Image
+	assert.Nil(t, db.Table(testTables.Images).Order("id").Find(&images).Error)
+	for _, i := range images {
+		assert.Nil(t, i.Blob)
+		assert.Equal(t, 1, i.Size)
+		assert.Equal(t, moved[i.Hash], []byte{byte(i.ItemID)})
+	}
+}
+
+func TestHashImages(t *testing.T) {
+	store := testStore(t)
+	db := store.(*gormStore).db
+
+	buff := &bytes.Buffer{}
+	assert.Nil(t, png.Encode(buff, image.NewGray(image.Rect(0, 0, 16, 16))))
+
+	// saved before images were hashed, one of them is not in the image store
+	img := testImage(1, buff.Bytes(), time.Now())
+	img.PHash, img.Blob = nil, nil
+	assert.Nil(t, store.UpsertImage(&img))
+	missing := testImage(2, []byte{2}, time.Now())
+	missing.PHash, missing.Blob = nil, nil
+	assert.Nil(t, store.UpsertImage(&missing))
+
+	files := map[string][]byte{img.Hash: buff.Bytes()}
+	count, err := store.HashImages(func(key string) ([]byte, error) {
+		if data, ok := files[key]; ok {
+			return data, nil
+		}
+
+		return nil, errors.New("not found")
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	var saved Image
+	assert.Nil(t, db.Table(testTables.Images).Where("id = ?", img.ID).First(&saved).Error)
+	assert.Equal(t, PHash(0), saved.PHash)
+}
@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const DefaultBatchSize = 100
+
+/*
+*
+Collects the rows of a scraper and writes them in batches of size, so a run is not dominated by a round trip
+per person. Saved is called with every written row and its outcome, after the batch of the row is flushed.
+Images are written after the rows, an image needs the id of its row.
+*/
+type Writer struct {
+	store  Store
+	size   int
+	raws   []Raw
+	saved  []func(raw Raw, outcome Outcome)
+	images []Image
+	seen   []string
+	seenAt time.Time
+	// called for a seen website id that has no row
+	unknown []func() error
+
+	// outcomes of the written rows
+	Counts map[Outcome]int
+	// rows and images that could not be written
+	Failed       int
+	FailedImages int
+}
+
+func NewWriter(store Store, size int) *Writer {
+	if size < 1 {
+		size = DefaultBatchSize
+	}
+
+	return &Writer{
+		store:  store,
+		size:   size,
+		raws:   make([]Raw, 0, size),
+		saved:  make([]func(Raw, Outcome), 0, size),
+		images: make([]Image, 0, size),
+		seen:   make([]string, 0, size),
+		Counts: make(map[Outcome]int),
+	}
+}
+
+func (w *Writer) AddRaw(raw Raw, saved func(raw Raw, outcome Outcome)) error {
+	w.raws = append(w.raws, raw)
+	w.saved = append(w.saved, saved)
+
+	if len(w.raws) >= w.size {
+		return w.flushRaws()
+	}
+
+	return nil
+}
+
+func (w *Writer) AddImage(image Image) error {
+	w.images = append(w.images, image)
+
+	if len(w.images) >= w.size {
+		return w.flushImages()
+	}
+
+	return nil
+}
+
+/*
+*
+Marks the person with the website id as seen at seenAt without writing the row again, for a page that did not
+change since the last run. The person counts as unchanged. If there is no row of the person, for example
+because the database is newer than the HTTP cache, unknown is called when the batch is flushed and should add
+the row.
+*/
+func (w *Writer) AddSeen(itemID string, seenAt time.Time, unknown func() error) error {
+	w.seen = append(w.seen, itemID)
+	w.unknown = append(w.unknown, unknown)
+	if seenAt.After(w.seenAt) {
+		w.seenAt = seenAt
+	}
+
+	if len(w.seen) >= w.size {
+		return w.flushSeen()
+	}
+
+	return nil
+}
+
+// Writes the rows and the images that are not written yet.
+func (w *Writer) Flush() error {
+	if err := w.flushSeen(); err != nil {
+		return err
+	}
+
+	if err := w.flushRaws(); err != nil {
+		return err
+	}
+
+	return w.flushImages()
+}
+
+/*
+*
+Drops the rows, seen persons and images that are not written yet, for a run that stopped because what it
+scrapped cannot be trusted. Returns the number of dropped rows and seen persons.
+*/
+func (w *Writer) Discard() int {
+	dropped := len(w.raws) + len(w.seen)
+
+	w.raws = make([]Raw, 0, w.size)
+	w.saved = make([]func(Raw, Outcome), 0, w.size)
+	w.seen = make([]string, 0, w.size)
+	w.unknown = make([]func() error, 0, w.size)
+	w.images = make([]Image, 0, w.size)
+
+	return dropped
+}
+
+func (w *Writer) flushSeen() error {
+	if len(w.seen) == 0 {
+		return nil
+	}
+
+	seen, unknown := w.seen, w.unknown
+	w.seen = make([]string, 0, w.size)
+	w.unknown = make([]func() error, 0, w.size)
+
+	missing, err := w.store.MarkSeen(seen, w.seenAt)
+	if err != nil {
+		w.Failed += len(seen)
+		return fmt.Errorf("failed marking %d persons as seen: %w", len(seen), err)
+	}
+
+	isMissing := make(map[string]bool, len(missing))
+	for _, id := range missing {
+		isMissing[id] = true
+	}
+
+	errs := make([]error, 0)
+	for i, id := range seen {
+		if !isMissing[id] {
+			w.Counts[OutcomeUnchanged]++
+			continue
+		}
+
+		if err := unknown[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (w *Writer) flushRaws() error {
+	if len(w.raws) == 0 {
+		return nil
+	}
+
+	raws, saved := w.raws, w.saved
+	w.raws = make([]Raw, 0, w.size)
+	w.saved = make([]func(Raw, Outcome), 0, w.size)
+
+	outcomes, err := w.store.UpsertRaws(raws)
+	if err != nil {
+		w.Failed += len(raws)
+		return fmt.Errorf("failed writing %d persons: %w", len(raws), err)
+	}
+
+	for i, r := range raws {
+		w.Counts[outcomes[i]]++
+		if saved[i] != nil {
+			saved[i](r, outcomes[i])
+		}
+	}
+
+	return nil
+}
+
+func (w *Writer) flushImages() error {
+	if len(w.images) == 0 {
+		return nil
+	}
+
+	images := w.images
+	w.images = make([]Image, 0, w.size)
+
+	if _, err := w.store.UpsertImages(images); err != nil {
+		w.FailedImages += len(images)
+		return fmt.Errorf("failed writing %d images: %w", len(images), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package warc
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// files are rotated when they reach this size, 1 GB is what most WARC tools expect
+const DefaultMaxSize = 1 << 30
+
+const Software = "missing-persons-scrapper"
+
+// Where the WARC files of the runs are written.
+type Archive struct {
+	Dir string
+	// a file is closed and the next one started once it has this many compressed bytes
+	MaxSize int64
+}
+
+/*
+*
+Archiving is optional, the archive is nil if ARCHIVE_DIR is not set. ARCHIVE_MAX_SIZE is the size in MB files
+are rotated at, 1024 by default.
+*/
+func FromEnv() (*Archive, error) {
+	dir := os.Getenv("ARCHIVE_DIR")
+	if dir == "" {
+		return nil, nil
+	}
+
+	maxSize := int64(DefaultMaxSize)
+	if value := os.Getenv("ARCHIVE_MAX_SIZE"); value != "" {
+		mb, err := strconv.Atoi(value)
+		if err != nil || mb < 1 {
+			return nil, fmt.Errorf("invalid ARCHIVE_MAX_SIZE %s, expected a number of MB", value)
+		}
+
+		maxSize = int64(mb) << 20
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Archive{Dir: dir, MaxSize: maxSize}, nil
+}
+
+/*
+*
+Writes the records of a single run to rotating files named <country>-<run id>-<start>-<number>.warc.gz. Every
+record is a gzip member of its own, so a file can be read from any record. Files are named .open while they
+are written. Every file starts with a warcinfo record that has the country and the run id.
+*/
+type Writer struct {
+	mu      sync.Mutex
+	archive Archive
+	country string
+	runID   int
+	started time.Time
+
+	number   int
+	file     *os.File
+	size     int64
+	infoID   string
+	fileName string
+}
+
+func (a *Archive) Open(country string, runID int, startedAt time.Time) (*Writer, error) {
+	w := &Writer{archive: *a, country: country, runID: runID, started: startedAt}
+	if err := w.rotate(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+/*
+*
+Returns the finished files of the country, of every run if runID is 0. Files that are still being written
+are not returned.
+*/
+func (a *Archive) Files(country string, runID int) ([]string, error) {
+	pattern := fmt.Sprintf("%s-*.warc.gz", country)
+	if runID != 0 {
+		pattern = fmt.Sprintf("%s-%d-*.warc.gz", country, runID)
+	}
+
+	return filepath.Glob(filepath.Join(a.Dir, pattern))
+}
+
+/*
+*
+Writes the records one after the other, records that belong together (a request and its response) are never
+split over two files.
+*/
+func (w *Writer) Write(records ...Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return fmt.Errorf("the archive of run %d is closed", w.runID)
+	}
+
+	if w.size >= w.archive.MaxSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range records {
+		if r.Type() != TypeWarcinfo {
+			r.Header.Set("WARC-Warcinfo-ID", w.infoID)
+		}
+
+		if err := w.write(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.closeFile()
+}
+
+func (w *Writer) write(r Record) error {
+	counter := &countingWriter{w: w.file}
+	gz := gzip.NewWriter(counter)
+	if _, err := r.WriteTo(gz); err != nil {
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	w.size += counter.n
+	return nil
+}
+
+func (w *Writer) rotate() error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+
+	w.number++
+	w.fileName = fmt.Sprintf("%s-%d-%s-%05d.warc.gz", w.country, w.runID, w.started.UTC().Format("20060102150405"), w.number)
+
+	f, err := os.OpenFile(filepath.Join(w.archive.Dir, w.fileName+".open"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	w.file = f
+	info := NewRecord(TypeWarcinfo, time.Now(), "application/warc-fields", InfoBlock(map[string]string{
+		"software":       Software,
+		"format":         "WARC File Format 1.1",
+		"country":        w.country,
+		"run-id":         strconv.Itoa(w.runID),
+		"run-started-at": w.started.UTC().Format(time.RFC3339),
+	}))
+	info.Header.Set("WARC-Filename", w.fileName)
+	w.infoID = info.ID()
+
+	// the warcinfo record does not count, so every file holds at least one record of the run
+	if err := w.write(info); err != nil {
+		return err
+	}
+
+	w.size = 0
+	return nil
+}
+
+func (w *Writer) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+
+	f := w.file
+	w.file = nil
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), filepath.Join(w.archive.Dir, w.fileName))
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,98 @@
+package warc
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"missing-persons-scrapper/pkg/httpClient"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type fetcher struct {
+	next   httpClient.Fetcher
+	writer *Writer
+	now    func() time.Time
+}
+
+/*
+*
+Returns a Fetcher that archives every page next fetched as a request and a response record. A page that did
+not change since the last run is archived as a revisit record of the same payload, the site only answered that
+it was not modified. Pages that could not be fetched at all are not archived.
+*/
+func NewFetcher(next httpClient.Fetcher, writer *Writer) httpClient.Fetcher {
+	return &fetcher{next: next, writer: writer, now: time.Now}
+}
+
+func (f *fetcher) Fetch(target string) (httpClient.Page, error) {
+	page, err := f.next.Fetch(target)
+	if err != nil {
+		return page, err
+	}
+
+	date := f.now()
+	request := NewRecord(TypeRequest, date, "application/http;msgtype=request", requestBlock(target))
+	request.Header.Set("WARC-Target-URI", target)
+
+	recordType := TypeResponse
+	if page.Unchanged {
+		recordType = TypeRevisit
+	}
+
+	response := NewRecord(recordType, date, "application/http;msgtype=response", nil)
+	response.Header.Set("WARC-Target-URI", target)
+	response.Header.Set("WARC-Payload-Digest", Digest(page.Body))
+	request.Header.Set("WARC-Concurrent-To", response.ID())
+
+	if page.Unchanged {
+		// only the headers, the payload is in the response record of an earlier run
+		response.Block = responseBlock(page, nil)
+		response.Header.Set("WARC-Profile", ProfileIdenticalPayload)
+		response.Header.Set("WARC-Refers-To-Target-URI", target)
+	} else {
+		response.Block = responseBlock(page, page.Body)
+	}
+	response.Header.Set("WARC-Block-Digest", Digest(response.Block))
+
+	// the page was fetched, the run goes on even if it could not be archived
+	if err := f.writer.Write(request, response); err != nil {
+		log.Println(fmt.Errorf("failed archiving %s: %w", target, err))
+	}
+
+	return page, nil
+}
+
+// the request as the fetcher sends it, without the conditional headers of the cache
+func requestBlock(target string) []byte {
+	buff := &bytes.Buffer{}
+
+	path, host := target, ""
+	if u, err := url.Parse(target); err == nil {
+		path, host = u.RequestURI(), u.Host
+	}
+
+	fmt.Fprintf(buff, "GET %s HTTP/1.1\r\nHost: %s\r\n\r\n", path, host)
+	return buff.Bytes()
+}
+
+func responseBlock(page httpClient.Page, body []byte) []byte {
+	buff := &bytes.Buffer{}
+	fmt.Fprintf(buff, "HTTP/1.1 %d %s\r\n", page.StatusCode, http.StatusText(page.StatusCode))
+
+	header := page.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	// the body was decoded and is archived as a whole
+	header.Del("Content-Encoding")
+	header.Del("Transfer-Encoding")
+	header.Set("Content-Length", fmt.Sprintf("%d", len(page.Body)))
+
+	header.Write(buff)
+	buff.WriteString("\r\n")
+	buff.Write(body)
+
+	return buff.Bytes()
+}
@@ -0,0 +1,141 @@
+package warc
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Reads the records of a WARC file, compressed (.warc.gz) or not.
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) (*Reader, error) {
+	buffered := bufio.NewReader(r)
+
+	// gzip files start with 1f 8b, every record is a member and the gzip reader reads them one after the other
+	magic, err := buffered.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+
+		buffered = bufio.NewReader(gz)
+	}
+
+	return &Reader{r: buffered}, nil
+}
+
+// Returns the next record, io.EOF after the last one.
+func (r *Reader) Next() (Record, error) {
+	line, err := r.r.ReadString('\n')
+	// the empty lines between records
+	for err == nil && strings.TrimSpace(line) == "" {
+		line, err = r.r.ReadString('\n')
+	}
+
+	if err != nil {
+		return Record{}, err
+	}
+
+	if !strings.HasPrefix(line, "WARC/") {
+		return Record{}, fmt.Errorf("invalid WARC record, expected a version line: %q", strings.TrimSpace(line))
+	}
+
+	header, err := textproto.NewReader(r.r).ReadMIMEHeader()
+	if err != nil {
+		return Record{}, fmt.Errorf("invalid WARC record header: %w", err)
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return Record{}, fmt.Errorf("invalid WARC record length: %w", err)
+	}
+
+	block := make([]byte, length)
+	if _, err := io.ReadFull(r.r, block); err != nil {
+		return Record{}, fmt.Errorf("truncated WARC record: %w", err)
+	}
+
+	return Record{Header: http.Header(header), Block: block}, nil
+}
+
+/*
+*
+Calls fn with every record of a compressed WARC file and the offset of its gzip member, the record can be read
+again with readAt. Every record has to be its own member, as the Writer writes them.
+*/
+func scan(path string, fn func(offset int64, record Record)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// the gzip reader reads a buffered reader byte by byte, so it never reads past the end of a member
+	counter := &countingReader{r: f}
+	buffered := bufio.NewReader(counter)
+	for {
+		offset := counter.n - int64(buffered.Buffered())
+
+		gz, err := gzip.NewReader(buffered)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%s at %d: %w", path, offset, err)
+		}
+		gz.Multistream(false)
+
+		record, err := (&Reader{r: bufio.NewReader(gz)}).Next()
+		if err != nil {
+			return fmt.Errorf("%s at %d: %w", path, offset, err)
+		}
+
+		fn(offset, record)
+
+		// the empty lines after the record
+		if _, err := io.Copy(io.Discard, gz); err != nil {
+			return fmt.Errorf("%s at %d: %w", path, offset, err)
+		}
+	}
+}
+
+// Reads the record of the gzip member at offset.
+func readAt(path string, offset int64) (Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Record{}, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return Record{}, err
+	}
+
+	gz, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return Record{}, err
+	}
+	gz.Multistream(false)
+
+	return (&Reader{r: bufio.NewReader(gz)}).Next()
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,135 @@
+package warc
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base32"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const Version = "WARC/1.1"
+
+const (
+	TypeWarcinfo = "warcinfo"
+	TypeRequest  = "request"
+	TypeResponse = "response"
+	TypeRevisit  = "revisit"
+)
+
+// the revisit profile of a page that is the same as when it was archived before
+const ProfileIdenticalPayload = "http://netpreserve.org/warc/1.1/revisit/identical-payload-digest"
+
+/*
+*
+A single WARC record (https://iipc.github.io/warc-specifications/specifications/warc-format/warc-1.1/).
+Header holds the named fields, Content-Length is set from the block when the record is written.
+*/
+type Record struct {
+	Header http.Header
+	Block  []byte
+}
+
+func NewRecord(recordType string, date time.Time, contentType string, block []byte) Record {
+	header := http.Header{}
+	header.Set("WARC-Type", recordType)
+	header.Set("WARC-Record-ID", NewRecordID())
+	header.Set("WARC-Date", date.UTC().Format(time.RFC3339))
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	return Record{Header: header, Block: block}
+}
+
+func NewRecordID() string {
+	return fmt.Sprintf("<urn:uuid:%s>", uuid.NewString())
+}
+
+func (r Record) Type() string {
+	return r.Header.Get("WARC-Type")
+}
+
+func (r Record) ID() string {
+	return r.Header.Get("WARC-Record-ID")
+}
+
+// Writes the record: the version line, the fields, an empty line, the block and two line breaks.
+func (r Record) WriteTo(w io.Writer) (int64, error) {
+	buff := &bytes.Buffer{}
+	buff.WriteString(Version + "\r\n")
+
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		if name != "Content-Length" {
+			names = append(names, name)
+		}
+	}
+	// WARC-Type first, it tells a reader what the record is
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "Warc-Type") != (names[j] == "Warc-Type") {
+			return names[i] == "Warc-Type"
+		}
+
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		for _, value := range r.Header[name] {
+			fmt.Fprintf(buff, "%s: %s\r\n", fieldName(name), value)
+		}
+	}
+
+	fmt.Fprintf(buff, "Content-Length: %d\r\n\r\n", len(r.Block))
+	buff.Write(r.Block)
+	buff.WriteString("\r\n\r\n")
+
+	return buff.WriteTo(w)
+}
+
+// http.Header canonicalizes WARC-Type to Warc-Type, the WARC fields are written as the specification names them
+var fieldNames = make(map[string]string)
+
+func init() {
+	for _, name := range []string{
+		"WARC-Type", "WARC-Record-ID", "WARC-Date", "WARC-Target-URI", "WARC-Concurrent-To", "WARC-Warcinfo-ID",
+		"WARC-Block-Digest", "WARC-Payload-Digest", "WARC-Refers-To-Target-URI", "WARC-Refers-To-Date",
+		"WARC-Profile", "WARC-Filename", "WARC-IP-Address",
+	} {
+		fieldNames[http.CanonicalHeaderKey(name)] = name
+	}
+}
+
+func fieldName(name string) string {
+	if specName, ok := fieldNames[name]; ok {
+		return specName
+	}
+
+	return name
+}
+
+// The digest of a block or payload as WARC writes it, sha1 in base32.
+func Digest(data []byte) string {
+	sum := sha1.Sum(data)
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
+
+// The block of a warcinfo record, fields are written as "name: value" lines.
+func InfoBlock(fields map[string]string) []byte {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buff := &bytes.Buffer{}
+	for _, name := range names {
+		fmt.Fprintf(buff, "%s: %s\r\n", name, fields[name])
+	}
+
+	return buff.Bytes()
+}
@@ -0,0 +1,88 @@
+package warc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"missing-persons-scrapper/pkg/httpClient"
+	"net/http"
+	"time"
+)
+
+var ErrNotArchived = errors.New("the page is not archived")
+
+// where the response of a URL is archived
+type location struct {
+	path   string
+	offset int64
+	date   time.Time
+}
+
+type replay struct {
+	newest map[string]location
+}
+
+/*
+*
+Returns a Fetcher that reads the pages from WARC files instead of fetching them, the newest archived response
+of every URL. Only the locations of the responses are kept, a page is read from its file when it is fetched.
+
+A revisit record is not a response, the page did not change since the response before it, so the newest
+response is the page the revisits refer to. A URL that is not archived fails with ErrNotArchived.
+*/
+func NewReplayFetcher(paths []string) (httpClient.Fetcher, error) {
+	r := &replay{newest: make(map[string]location)}
+
+	for _, path := range paths {
+		err := scan(path, func(offset int64, record Record) {
+			target := record.Header.Get("WARC-Target-URI")
+			if record.Type() != TypeResponse || target == "" {
+				return
+			}
+
+			date, err := time.Parse(time.RFC3339, record.Header.Get("WARC-Date"))
+			if err != nil {
+				return
+			}
+
+			// files can be passed in any order, the date decides
+			if current, ok := r.newest[target]; ok && current.date.After(date) {
+				return
+			}
+
+			r.newest[target] = location{path: path, offset: offset, date: date}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func (r *replay) Fetch(url string) (httpClient.Page, error) {
+	loc, ok := r.newest[url]
+	if !ok {
+		return httpClient.Page{}, fmt.Errorf("%w: %s", ErrNotArchived, url)
+	}
+
+	record, err := readAt(loc.path, loc.offset)
+	if err != nil {
+		return httpClient.Page{}, err
+	}
+
+	res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(record.Block)), nil)
+	if err != nil {
+		return httpClient.Page{}, fmt.Errorf("invalid archived response of %s: %w", url, err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return httpClient.Page{}, err
+	}
+
+	return httpClient.Page{URL: url, StatusCode: res.StatusCode, Header: res.Header, Body: body}, nil
+}
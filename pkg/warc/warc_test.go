@@ -0,0 +1,157 @@
+package warc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"missing-persons-scrapper/pkg/httpClient"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeFetcher map[string]httpClient.Page
+
+func (f fakeFetcher) Fetch(url string) (httpClient.Page, error) {
+	return f[url], nil
+}
+
+func readAll(t *testing.T, path string) []Record {
+	f, err := os.Open(path)
+	assert.Nil(t, err)
+	defer f.Close()
+
+	r, err := NewReader(f)
+	assert.Nil(t, err)
+
+	records := make([]Record, 0)
+	for {
+		record, err := r.Next()
+		if err != nil {
+			assert.Equal(t, "EOF", err.Error())
+			return records
+		}
+
+		records = append(records, record)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	archive := &Archive{Dir: t.TempDir(), MaxSize: DefaultMaxSize}
+	started := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	writer, err := archive.Open("hr", 7, started)
+	assert.Nil(t, err)
+
+	fetcher := NewFetcher(fakeFetcher{
+		"https://nestali.gov.hr/a": {StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"text/html"}}, Body: []byte("<html>a</html>")},
+		"https://nestali.gov.hr/b": {StatusCode: http.StatusOK, Body: []byte("<html>b</html>"), Unchanged: true},
+	}, writer)
+
+	page, err := fetcher.Fetch("https://nestali.gov.hr/a")
+	assert.Nil(t, err)
+	assert.Equal(t, "<html>a</html>", string(page.Body))
+	_, err = fetcher.Fetch("https://nestali.gov.hr/b")
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+
+	files, err := filepath.Glob(filepath.Join(archive.Dir, "*"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(archive.Dir, "hr-7-20240301120000-00001.warc.gz")}, files)
+
+	records := readAll(t, files[0])
+	assert.Len(t, records, 5)
+
+	assert.Equal(t, TypeWarcinfo, records[0].Type())
+	assert.Contains(t, string(records[0].Block), "run-id: 7")
+
+	assert.Equal(t, TypeRequest, records[1].Type())
+	assert.Equal(t, records[2].ID(), records[1].Header.Get("WARC-Concurrent-To"))
+	assert.Equal(t, records[0].ID(), records[1].Header.Get("WARC-Warcinfo-ID"))
+
+	assert.Equal(t, TypeResponse, records[2].Type())
+	assert.Equal(t, "https://nestali.gov.hr/a", records[2].Header.Get("WARC-Target-URI"))
+	assert.True(t, strings.HasPrefix(string(records[2].Block), "HTTP/1.1 200 OK\r\n"))
+	assert.True(t, strings.HasSuffix(string(records[2].Block), "\r\n\r\n<html>a</html>"))
+	assert.Equal(t, Digest([]byte("<html>a</html>")), records[2].Header.Get("WARC-Payload-Digest"))
+
+	// the unchanged page has no payload
+	assert.Equal(t, TypeRevisit, records[4].Type())
+	assert.Equal(t, ProfileIdenticalPayload, records[4].Header.Get("WARC-Profile"))
+	assert.NotContains(t, string(records[4].Block), "<html>b</html>")
+}
+
+func TestRotation(t *testing.T) {
+	archive := &Archive{Dir: t.TempDir(), MaxSize: 1}
+	writer, err := archive.Open("ro", 3, time.Now())
+	assert.Nil(t, err)
+
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, writer.Write(NewRecord(TypeResponse, time.Now(), "text/plain", []byte("x"))))
+	}
+	assert.Nil(t, writer.Close())
+
+	files, err := filepath.Glob(filepath.Join(archive.Dir, "ro-3-*.warc.gz"))
+	assert.Nil(t, err)
+	assert.Len(t, files, 3)
+
+	// every file starts with its own warcinfo record
+	for _, f := range files {
+		records := readAll(t, f)
+		assert.Len(t, records, 2)
+		assert.Equal(t, TypeWarcinfo, records[0].Type())
+		assert.Equal(t, filepath.Base(f), records[0].Header.Get("WARC-Filename"))
+	}
+}
+
+// archives the pages as a run fetching them one after the other, an hour apart
+func archiveRun(t *testing.T, archive *Archive, runID int, startedAt time.Time, pages ...httpClient.Page) {
+	writer, err := archive.Open("hr", runID, startedAt)
+	assert.Nil(t, err)
+
+	for i, page := range pages {
+		f := NewFetcher(fakeFetcher{page.URL: page}, writer).(*fetcher)
+		f.now = func() time.Time { return startedAt.Add(time.Duration(i) * time.Hour) }
+		_, err := f.Fetch(page.URL)
+		assert.Nil(t, err)
+	}
+
+	assert.Nil(t, writer.Close())
+}
+
+func TestReplay(t *testing.T) {
+	archive := &Archive{Dir: t.TempDir(), MaxSize: DefaultMaxSize}
+	url := "https://nestali.gov.hr/a"
+
+	archiveRun(t, archive, 1, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		httpClient.Page{URL: url, StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"text/html"}}, Body: []byte("<html>first</html>")})
+	// the page changed, then it was not modified
+	archiveRun(t, archive, 2, time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC),
+		httpClient.Page{URL: url, StatusCode: http.StatusOK, Body: []byte("<html>second</html>")},
+		httpClient.Page{URL: url, StatusCode: http.StatusOK, Body: []byte("<html>second</html>"), Unchanged: true})
+
+	files, err := archive.Files("hr", 0)
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+
+	// the newest response whatever the order of the files, the revisit has no payload
+	replay, err := NewReplayFetcher([]string{files[1], files[0]})
+	assert.Nil(t, err)
+	page, err := replay.Fetch(url)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, page.StatusCode)
+	assert.Equal(t, "<html>second</html>", string(page.Body))
+
+	files, err = archive.Files("hr", 1)
+	assert.Nil(t, err)
+	replay, err = NewReplayFetcher(files)
+	assert.Nil(t, err)
+	page, err = replay.Fetch(url)
+	assert.Nil(t, err)
+	assert.Equal(t, "<html>first</html>", string(page.Body))
+	assert.Equal(t, "text/html", page.Header.Get("Content-Type"))
+
+	_, err = replay.Fetch("https://nestali.gov.hr/b")
+	assert.ErrorIs(t, err, ErrNotArchived)
+}